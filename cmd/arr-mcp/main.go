@@ -2,29 +2,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
+	"time"
 
-	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/audit"
 	"github.com/GauranshMathur/ARR_MCP/pkg/config"
 	"github.com/GauranshMathur/ARR_MCP/pkg/logger"
 	"github.com/GauranshMathur/ARR_MCP/pkg/server"
+	"github.com/GauranshMathur/ARR_MCP/pkg/tracing"
 )
 
-// specs maps a service name to the API description used to reach it.
-var specs = map[string]arr.ServiceSpec{
-	"sonarr":   arr.SonarrSpec,
-	"radarr":   arr.RadarrSpec,
-	"prowlarr": arr.ProwlarrSpec,
-	"bazarr":   arr.BazarrSpec,
-}
-
 func main() {
 	var (
 		configPath = flag.String("config", os.Getenv("ARR_MCP_CONFIG"), "path to config.yaml; omit to configure from environment variables")
@@ -32,6 +27,8 @@ func main() {
 		addr       = flag.String("addr", "", "listen address for the http transport (overrides config)")
 		logLevel   = flag.String("log-level", "", "log level: debug, info, warn, error (overrides config)")
 		check      = flag.Bool("check", false, "check connectivity to every configured instance and exit")
+		watch      = flag.Duration("watch-config", 0, "also reload --config when its contents change, checking this often (e.g. 10s); SIGHUP always reloads")
+		dryRun     = flag.Bool("dry-run", false, "report the request every mutating tool would send instead of sending it (overrides config)")
 		showVer    = flag.Bool("version", false, "print the version and exit")
 	)
 	flag.Parse()
@@ -49,21 +46,28 @@ func main() {
 
 	// Flags win over the config file so a container can be retargeted without
 	// rewriting its mounted configuration.
-	if *transport != "" {
-		cfg.Server.Transport = *transport
-	}
-	if *addr != "" {
-		cfg.Server.Addr = *addr
-	}
-	if *logLevel != "" {
-		cfg.Server.LogLevel = *logLevel
+	applyFlags := func(cfg *config.Config) {
+		if *transport != "" {
+			cfg.Server.Transport = *transport
+		}
+		if *addr != "" {
+			cfg.Server.Addr = *addr
+		}
+		if *logLevel != "" {
+			cfg.Server.LogLevel = *logLevel
+		}
+		if *dryRun {
+			cfg.Server.DryRun = true
+		}
 	}
+	applyFlags(cfg)
 
 	// Logging goes to stderr: under stdio, stdout carries the JSON-RPC stream.
 	log := logger.New(cfg.Server.LogLevel, "arr-mcp")
+	warnInsecure(log, cfg)
 
 	if *check {
-		os.Exit(checkAll(cfg, log))
+		os.Exit(checkAll(cfg))
 	}
 
 	for _, svc := range cfg.ConfiguredServices() {
@@ -72,11 +76,59 @@ func main() {
 	}
 	log.Info("permissions: mode=%s confirmScope=%s fallback=%s",
 		cfg.Permissions.Mode, cfg.Permissions.ConfirmScope, cfg.Permissions.Fallback)
+	if n := len(cfg.Server.Auth.Tokens); n > 0 {
+		log.Info("auth: %d bearer token(s) accepted on the http transport", n)
+	}
+	if o := cfg.Server.Auth.OAuth; o != nil {
+		log.Info("auth: OAuth access tokens from %s accepted for %s", o.Issuer, o.Audience)
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	auditLog, err := audit.Open(cfg.Server.Audit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit log: %v\n", err)
+		os.Exit(1)
+	}
+	if auditLog != nil {
+		log.Info("audit: recording mutating tool calls to %s", cfg.Server.Audit.Path)
+	}
+	defer func() { _ = auditLog.Close() }()
+
+	if exporter := tracing.Enable(cfg.Server.Tracing, server.Version); exporter != nil {
+		exporter.OnError = func(err error) { log.Warn("tracing: %v", err) }
+		log.Info("tracing: exporting spans to %s", cfg.Server.Tracing.Endpoint)
+		defer func() {
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			exporter.Shutdown(flushCtx)
+		}()
+	}
+
 	s := server.New(cfg, log)
+	s.SetAuditLog(auditLog)
+
+	reload := func() {
+		if *configPath == "" {
+			log.Warn("reload: nothing to reload; the configuration came from environment variables")
+			return
+		}
+		next, err := config.Load(*configPath)
+		if err != nil {
+			log.Error("reload rejected, keeping the running configuration: %v", err)
+			return
+		}
+		applyFlags(next)
+		log.SetLevel(next.Server.LogLevel)
+		warnInsecure(log, next)
+		for _, setting := range s.Reload(next) {
+			log.Warn("reload: %s changed but only takes effect after a restart", setting)
+		}
+		log.Info("reload: configuration reloaded from %s; %d service(s) configured",
+			*configPath, len(next.ConfiguredServices()))
+	}
+	go watchConfig(ctx, *configPath, *watch, reload)
 
 	switch cfg.Server.Transport {
 	case "stdio":
@@ -95,44 +147,71 @@ func main() {
 	log.Info("shutdown complete")
 }
 
-// checkAll pings every configured instance in parallel and reports the results.
-// It returns a process exit code.
-func checkAll(cfg *config.Config, log *logger.Logger) int {
-	ctx := context.Background()
-	var (
-		mu     sync.Mutex
-		wg     sync.WaitGroup
-		failed bool
-	)
+// warnInsecure warns about every instance whose certificate is not verified.
+func warnInsecure(log *logger.Logger, cfg *config.Config) {
+	for _, name := range cfg.InsecureInstances() {
+		log.Warn("%s: tls.insecureSkipVerify is set; its certificate is not verified, so the "+
+			"connection and API key can be intercepted", name)
+	}
+}
 
-	for _, svc := range cfg.ConfiguredServices() {
-		spec, ok := specs[svc]
-		if !ok {
-			continue
-		}
-		for i := range cfg.Services[svc] {
-			inst := cfg.Services[svc][i]
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				client := arr.NewClient(inst.URL, spec, arr.Credentials{APIKey: inst.APIKey})
-				err := client.Ping(ctx)
-
-				mu.Lock()
-				defer mu.Unlock()
-				if err != nil {
-					failed = true
-					fmt.Printf("FAIL  %s/%s (%s): %v\n", svc, inst.Name, inst.URL, err)
-					return
-				}
-				fmt.Printf("OK    %s/%s (%s)\n", svc, inst.Name, inst.URL)
-			}()
+// watchConfig calls reload on every SIGHUP and, when interval is set, each
+// time the contents of path change. Reloads run one at a time until ctx ends.
+func watchConfig(ctx context.Context, path string, interval time.Duration, reload func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 && path != "" {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	last := fingerprint(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			last = fingerprint(path)
+			reload()
+		case <-tick:
+			// A file that cannot be read is usually being replaced; look again
+			// on the next tick.
+			if sum := fingerprint(path); sum != nil && !bytes.Equal(sum, last) {
+				last = sum
+				reload()
+			}
 		}
 	}
-	wg.Wait()
+}
+
+// fingerprint hashes the contents of path, or returns nil if it cannot be
+// read.
+func fingerprint(path string) []byte {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- the operator's --config
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
 
-	if failed {
-		return 1
+// checkAll pings every configured instance in parallel and reports the results.
+// It returns a process exit code.
+func checkAll(cfg *config.Config) int {
+	code := 0
+	for _, r := range server.CheckInstances(context.Background(), cfg) {
+		if !r.OK {
+			code = 1
+			fmt.Printf("FAIL  %s/%s (%s): %s\n", r.Service, r.Instance, r.URL, r.Detail)
+			continue
+		}
+		fmt.Printf("OK    %s/%s (%s)\n", r.Service, r.Instance, r.URL)
 	}
-	return 0
+	return code
 }
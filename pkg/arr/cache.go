@@ -0,0 +1,111 @@
+package arr
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheTTLs lists the read endpoints worth caching, with how long a response
+// stays fresh. Each returns the whole library or catalogue in one payload of
+// often hundreds of kilobytes, which a model tends to request several times in
+// one conversation. Anything not listed is always fetched.
+var cacheTTLs = map[string]time.Duration{
+	"/series":       time.Minute,
+	"/movie":        time.Minute,
+	"/collection":   5 * time.Minute,
+	"/customformat": 5 * time.Minute,
+}
+
+type noCacheKey struct{}
+
+// NoCache returns a context under which reads skip the response cache and
+// fetch from the service, refreshing the cached copy on the way.
+func NoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// responseCache holds recent GET responses for one instance. Clients made by
+// WithTimeout share their parent's cache.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+	// root is the first path segment, the unit writes invalidate.
+	root string
+}
+
+// cacheKey identifies a read by path and query.
+func cacheKey(path string, q Query) string {
+	if len(q) == 0 {
+		return path
+	}
+	values := url.Values{}
+	for k, v := range q {
+		values.Set(k, v)
+	}
+	return path + "?" + values.Encode()
+}
+
+// rootOf returns the first segment of path: "/series/12/episodes" → "/series".
+func rootOf(path string) string {
+	path = "/" + strings.TrimLeft(path, "/")
+	if i := strings.IndexByte(path[1:], '/'); i >= 0 {
+		return path[:i+1]
+	}
+	return path
+}
+
+// get returns a fresh cached body. Callers must not modify it.
+func (rc *responseCache) get(ctx context.Context, key string) ([]byte, bool) {
+	if ctx.Value(noCacheKey{}) != nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e, ok := rc.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.body, true
+}
+
+func (rc *responseCache) put(path, key string, body []byte, ttl time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.entries == nil {
+		rc.entries = map[string]cacheEntry{}
+	}
+	rc.entries[key] = cacheEntry{body: body, expires: time.Now().Add(ttl), root: rootOf(path)}
+}
+
+// libraryRoots are the cached listings that summarise the library: file
+// counts and sizes, monitored state and tags. Writes elsewhere feed into them,
+// from deleting an episode or movie file and monitoring episodes to removing
+// a tag or importing files by hand, so any write drops them.
+var libraryRoots = []string{"/series", "/movie", "/collection"}
+
+// invalidate drops what a write to path may have changed: the library
+// listings, entries under the same root, or everything for a command, since
+// commands such as a refresh or rescan can touch any part of the library.
+func (rc *responseCache) invalidate(method, path string) {
+	if method == http.MethodGet {
+		return
+	}
+	root := rootOf(path)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for k, e := range rc.entries {
+		if root == "/command" || e.root == root || slices.Contains(libraryRoots, e.root) {
+			delete(rc.entries, k)
+		}
+	}
+}
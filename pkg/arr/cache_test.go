@@ -0,0 +1,119 @@
+package arr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// countingService answers every request with body and counts GETs per path.
+func countingService(t *testing.T, body string) (*Client, map[string]int) {
+	t.Helper()
+	gets := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets[r.URL.Path]++
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}), gets
+}
+
+func TestListingsAreCachedAndOtherReadsAreNot(t *testing.T) {
+	c, gets := countingService(t, `[]`)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := SonarrListSeries(ctx, c); err != nil {
+			t.Fatalf("SonarrListSeries: %v", err)
+		}
+		if _, err := c.Get(ctx, "/queue"); err != nil {
+			t.Fatalf("Get /queue: %v", err)
+		}
+	}
+	if gets["/api/v3/series"] != 1 {
+		t.Errorf("series fetched %d times, want 1 from cache", gets["/api/v3/series"])
+	}
+	if gets["/api/v3/queue"] != 3 {
+		t.Errorf("queue fetched %d times, want every call to reach the service", gets["/api/v3/queue"])
+	}
+}
+
+func TestWritesInvalidateTheMatchingListing(t *testing.T) {
+	c, gets := countingService(t, `[]`)
+	ctx := context.Background()
+
+	_, _ = SonarrListSeries(ctx, c)
+	_, _ = ListCustomFormats(ctx, c)
+	if _, err := c.Put(ctx, "/series/7", map[string]any{"monitored": false}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	_, _ = SonarrListSeries(ctx, c)
+	_, _ = ListCustomFormats(ctx, c)
+
+	if gets["/api/v3/series"] != 2 {
+		t.Errorf("series fetched %d times, want a refetch after the write", gets["/api/v3/series"])
+	}
+	if gets["/api/v3/customformat"] != 1 {
+		t.Errorf("custom formats fetched %d times, want the unrelated entry kept", gets["/api/v3/customformat"])
+	}
+
+	if _, err := c.Post(ctx, "/command", map[string]any{"name": "RefreshSeries"}); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	_, _ = ListCustomFormats(ctx, c)
+	if gets["/api/v3/customformat"] != 2 {
+		t.Errorf("custom formats fetched %d times, want a command to clear the whole cache", gets["/api/v3/customformat"])
+	}
+}
+
+// Deleting a file or a tag changes what the series listing shows, though
+// neither is written under /series.
+func TestWritesElsewhereInvalidateTheLibraryListings(t *testing.T) {
+	for _, path := range []string{"/episodefile/4", "/tag/2", "/episode/monitor", "/manualimport"} {
+		c, gets := countingService(t, `[]`)
+		ctx := context.Background()
+
+		_, _ = SonarrListSeries(ctx, c)
+		_, _ = ListCustomFormats(ctx, c)
+		if _, err := c.Put(ctx, path, map[string]any{}); err != nil {
+			t.Fatalf("%s: Put: %v", path, err)
+		}
+		_, _ = SonarrListSeries(ctx, c)
+		_, _ = ListCustomFormats(ctx, c)
+
+		if gets["/api/v3/series"] != 2 {
+			t.Errorf("%s: series fetched %d times, want a refetch after the write", path, gets["/api/v3/series"])
+		}
+		if gets["/api/v3/customformat"] != 1 {
+			t.Errorf("%s: custom formats fetched %d times, want them kept", path, gets["/api/v3/customformat"])
+		}
+	}
+}
+
+func TestNoCacheBypassesAndRefreshes(t *testing.T) {
+	c, gets := countingService(t, `[]`)
+	ctx := context.Background()
+
+	_, _ = SonarrListSeries(ctx, c)
+	_, _ = SonarrListSeries(NoCache(ctx), c)
+	_, _ = SonarrListSeries(ctx, c)
+	if gets["/api/v3/series"] != 2 {
+		t.Errorf("series fetched %d times, want only the bypassing call to refetch", gets["/api/v3/series"])
+	}
+}
+
+func TestRootOf(t *testing.T) {
+	for in, want := range map[string]string{
+		"/series":             "/series",
+		"series/12":           "/series",
+		"/series/12/episodes": "/series",
+		"/movie/editor":       "/movie",
+	} {
+		if got := rootOf(in); got != want {
+			t.Errorf("rootOf(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -4,13 +4,22 @@ package arr
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/metrics"
+	"github.com/GauranshMathur/ARR_MCP/pkg/tracing"
 )
 
 // AuthKind selects how credentials are attached to outbound requests.
@@ -41,16 +50,59 @@ type ServiceSpec struct {
 	// Only the name is meaningful: net/http canonicalises header casing, so a
 	// spec cannot request a differently-cased spelling of the same name.
 	AuthHeader string
+	// Commands names the background commands /command runs without further
+	// arguments. They are what run_command offers unless configuration lists
+	// its own; services without a /command endpoint leave it empty. Library-wide
+	// rescans are left out, for configuration to opt into.
+	Commands []string
+}
+
+// sharedCommands are the housekeeping commands every media service accepts.
+var sharedCommands = []string{
+	"RssSync", "ImportListSync", "RefreshMonitoredDownloads", "CheckHealth", "Backup",
+	"Housekeeping", "CleanUpRecycleBin", "ClearBlocklist",
 }
 
 // Specs for the services this build supports.
 var (
 	// SonarrSpec describes Sonarr's v3 API.
-	SonarrSpec = ServiceSpec{Name: "sonarr", BasePath: "/api/v3", StatusPath: "/system/status", Auth: AuthHeaderKey}
+	SonarrSpec = ServiceSpec{
+		Name: "sonarr", BasePath: "/api/v3", StatusPath: "/system/status", Auth: AuthHeaderKey,
+		Commands: append([]string{
+			"RefreshSeries", "RescanSeries", "MissingEpisodeSearch", "CutoffUnmetEpisodeSearch",
+			"DownloadedEpisodesScan",
+		}, sharedCommands...),
+	}
 	// RadarrSpec describes Radarr's v3 API.
-	RadarrSpec = ServiceSpec{Name: "radarr", BasePath: "/api/v3", StatusPath: "/system/status", Auth: AuthHeaderKey}
+	RadarrSpec = ServiceSpec{
+		Name: "radarr", BasePath: "/api/v3", StatusPath: "/system/status", Auth: AuthHeaderKey,
+		Commands: append([]string{
+			"RefreshMovie", "RescanMovie", "MissingMoviesSearch", "CutoffUnmetMoviesSearch",
+			"DownloadedMoviesScan", "RefreshCollections",
+		}, sharedCommands...),
+	}
+	// LidarrSpec describes Lidarr's v1 API. It shares the Sonarr/Radarr resource
+	// shapes for queue, history, tags and commands, just one version lower.
+	LidarrSpec = ServiceSpec{
+		Name: "lidarr", BasePath: "/api/v1", StatusPath: "/system/status", Auth: AuthHeaderKey,
+		Commands: append([]string{
+			"RefreshArtist", "MissingAlbumSearch", "CutoffUnmetAlbumSearch",
+			"DownloadedAlbumsScan",
+		}, sharedCommands...),
+	}
+	// ReadarrSpec describes Readarr's v1 API, the same generation as Lidarr's.
+	ReadarrSpec = ServiceSpec{
+		Name: "readarr", BasePath: "/api/v1", StatusPath: "/system/status", Auth: AuthHeaderKey,
+		Commands: append([]string{
+			"RefreshAuthor", "MissingBookSearch", "CutoffUnmetBookSearch",
+			"DownloadedBooksScan",
+		}, sharedCommands...),
+	}
 	// ProwlarrSpec describes Prowlarr's v1 API, which differs from Sonarr/Radarr.
-	ProwlarrSpec = ServiceSpec{Name: "prowlarr", BasePath: "/api/v1", StatusPath: "/system/status", Auth: AuthHeaderKey}
+	ProwlarrSpec = ServiceSpec{
+		Name: "prowlarr", BasePath: "/api/v1", StatusPath: "/system/status", Auth: AuthHeaderKey,
+		Commands: []string{"ApplicationIndexerSync", "Backup", "CheckHealth", "Housekeeping"},
+	}
 	// BazarrSpec describes Bazarr, which serves /api rather than a versioned
 	// path. It accepts the canonical X-Api-Key header, so no override is needed.
 	BazarrSpec = ServiceSpec{
@@ -59,14 +111,29 @@ var (
 	}
 )
 
+// Specs maps each supported service name, as used in configuration, to its spec.
+var Specs = map[string]ServiceSpec{
+	"sonarr":   SonarrSpec,
+	"radarr":   RadarrSpec,
+	"lidarr":   LidarrSpec,
+	"readarr":  ReadarrSpec,
+	"prowlarr": ProwlarrSpec,
+	"bazarr":   BazarrSpec,
+}
+
 // defaultTimeout bounds ordinary reads and fire-and-forget commands.
 const defaultTimeout = 30 * time.Second
 
 // Credentials carries the secrets for whichever auth scheme a spec selects.
+// Under AuthHeaderKey a username and password are sent as basic credentials
+// as well, for an auth proxy in front of the service.
 type Credentials struct {
 	APIKey   string
 	Username string
 	Password string
+	// Headers are added to every request, also for an auth proxy. They are
+	// treated as secrets.
+	Headers map[string]string
 }
 
 // Query is a set of URL query parameters.
@@ -78,26 +145,76 @@ type Client struct {
 	spec    ServiceSpec
 	creds   Credentials
 	http    *http.Client
+	cache   *responseCache
+	policy  RetryPolicy
+	breaker *breaker
 }
 
-// NewClient creates a client for a single instance of the service in spec.
+// NewClient creates a client for a single instance of the service in spec,
+// retrying and breaking per DefaultRetryPolicy. Each client owns its
+// connection pool and circuit breaker, so it is meant to be kept and shared
+// for as long as the instance's configuration stays the same.
 func NewClient(baseURL string, spec ServiceSpec, creds Credentials) *Client {
-	return &Client{
+	c := &Client{
 		baseURL: strings.TrimRight(baseURL, "/"),
 		spec:    spec,
 		creds:   creds,
-		http:    &http.Client{Timeout: defaultTimeout},
+		http:    &http.Client{Timeout: defaultTimeout, Transport: newTransport()},
+		cache:   &responseCache{},
 	}
+	return c.WithPolicy(DefaultRetryPolicy)
 }
 
+// newTransport tunes connection reuse for one instance: enough idle
+// connections that parallel tool calls skip the TCP and TLS handshakes, a cap
+// so a burst cannot flood the service, and HTTP/2 wherever it is offered.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 32
+	t.MaxIdleConnsPerHost = 16
+	t.MaxConnsPerHost = 32
+	t.IdleConnTimeout = 90 * time.Second
+	t.ForceAttemptHTTP2 = true
+	return t
+}
+
+// CloseIdleConnections releases pooled connections, for a client that is
+// being replaced or was only needed once.
+func (c *Client) CloseIdleConnections() { c.http.CloseIdleConnections() }
+
 // Spec returns the service description this client was built from.
 func (c *Client) Spec() ServiceSpec { return c.spec }
 
 // WithTimeout returns a copy of the client using a different request timeout,
-// for calls that legitimately run longer than a read. The original is unchanged.
+// for calls that legitimately run longer than a read. The original is unchanged
+// and the copy shares its connection pool.
 func (c *Client) WithTimeout(d time.Duration) *Client {
 	clone := *c
-	clone.http = &http.Client{Timeout: d}
+	clone.http = &http.Client{Timeout: d, Transport: c.http.Transport}
+	return &clone
+}
+
+// WithTLS returns a copy of the client that secures connections with cfg, for
+// instances behind a private CA or an ingress requiring a client certificate.
+// The copy has a connection pool of its own. A nil cfg returns c unchanged.
+func (c *Client) WithTLS(cfg *tls.Config) *Client {
+	if cfg == nil {
+		return c
+	}
+	t := newTransport()
+	t.TLSClientConfig = cfg
+	clone := *c
+	clone.http = &http.Client{Timeout: c.http.Timeout, Transport: t}
+	return &clone
+}
+
+// WithPolicy returns a copy of the client that retries and breaks as p says.
+// The copy shares the connection pool and cache but has a breaker of its own,
+// so it is meant to replace the original rather than sit alongside it.
+func (c *Client) WithPolicy(p RetryPolicy) *Client {
+	clone := *c
+	clone.policy = p
+	clone.breaker = newBreaker(p.BreakerThreshold, p.BreakerCooldown)
 	return &clone
 }
 
@@ -120,8 +237,12 @@ func (c *Client) resolve(path string, q Query) (string, error) {
 	return u.String(), nil
 }
 
-// authorize attaches credentials according to the service spec.
+// authorize attaches credentials according to the service spec. Extra
+// headers go first so none of them can replace the spec's own credential.
 func (c *Client) authorize(req *http.Request) {
+	for k, v := range c.creds.Headers {
+		req.Header.Set(k, v)
+	}
 	switch c.spec.Auth {
 	case AuthHeaderKey:
 		header := c.spec.AuthHeader
@@ -129,6 +250,9 @@ func (c *Client) authorize(req *http.Request) {
 			header = "X-Api-Key"
 		}
 		req.Header.Set(header, c.creds.APIKey)
+		if c.creds.Username != "" {
+			req.SetBasicAuth(c.creds.Username, c.creds.Password)
+		}
 	case AuthBasic:
 		req.SetBasicAuth(c.creds.Username, c.creds.Password)
 	case AuthNone:
@@ -143,9 +267,18 @@ func (c *Client) authorize(req *http.Request) {
 // no secrecy anyway.
 const minRedactable = 8
 
-// redact removes credentials from text bound for logs or model-visible errors.
+// redact removes credentials from text bound for logs or model-visible errors:
+// the key, the password, the encoded basic credentials a proxy might echo
+// back, and every extra header value.
 func (c *Client) redact(s string) string {
-	for _, secret := range []string{c.creds.APIKey, c.creds.Password} {
+	secrets := []string{c.creds.APIKey, c.creds.Password}
+	if c.creds.Username != "" {
+		secrets = append(secrets, base64.StdEncoding.EncodeToString([]byte(c.creds.Username+":"+c.creds.Password)))
+	}
+	for _, v := range c.creds.Headers {
+		secrets = append(secrets, v)
+	}
+	for _, secret := range secrets {
 		if len(secret) >= minRedactable {
 			s = strings.ReplaceAll(s, secret, "***")
 		}
@@ -153,7 +286,10 @@ func (c *Client) redact(s string) string {
 	return s
 }
 
-// do performs a request and returns the response body.
+// do performs a request and returns the response body, retrying transient
+// failures as the client's policy allows. Calls to an instance whose breaker
+// is open fail at once with ErrUnavailable. Under DryRun, requests other than
+// reads are recorded and answered without being sent.
 func (c *Client) do(ctx context.Context, method, path string, body any, q Query) ([]byte, error) {
 	target, err := c.resolve(path, q)
 	if err != nil {
@@ -161,8 +297,9 @@ func (c *Client) do(ctx context.Context, method, path string, body any, q Query)
 	}
 
 	var payload io.Reader
+	var encoded []byte
 	if body != nil {
-		encoded, err := json.Marshal(body)
+		encoded, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("encoding %s request body: %w", c.spec.Name, err)
 		}
@@ -178,28 +315,130 @@ func (c *Client) do(ctx context.Context, method, path string, body any, q Query)
 		req.Header.Set("Content-Type", "application/json")
 	}
 	c.authorize(req)
+	if reply, held := planFrom(ctx).hold(method, req.URL.RequestURI(), encoded); held {
+		return reply, nil
+	}
 
+	if wait, ok := c.breaker.allow(); !ok {
+		metrics.BreakerRejections.Inc(c.spec.Name)
+		return nil, fmt.Errorf("%s %w: %d calls in a row failed; retrying in %s",
+			c.spec.Name, ErrUnavailable, c.policy.BreakerThreshold, wait.Round(time.Second))
+	}
+
+	var a attempt
+	for n := 0; ; n++ {
+		a = c.send(ctx, req, n)
+		if n >= c.policy.Retries || !a.retryable(method) || ctx.Err() != nil {
+			break
+		}
+		wait, ok := c.backoff(n, a.retryAfter)
+		if !ok || !sleep(ctx, wait) {
+			break
+		}
+		metrics.UpstreamRetries.Inc(c.spec.Name, method)
+	}
+	if ctx.Err() != nil && !a.healthy() {
+		c.breaker.release()
+	} else {
+		c.breaker.record(a.healthy())
+	}
+
+	// Invalidate even when the write failed: it may have been applied
+	// before the error.
+	c.cache.invalidate(method, path)
+	return a.body, a.err
+}
+
+// send makes attempt n at req, starting from 0. Each attempt gets its own
+// copy of the request and body, since a body can only be read once.
+func (c *Client) send(ctx context.Context, req *http.Request, n int) attempt {
+	req = req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return attempt{err: fmt.Errorf("building %s request: %w", c.spec.Name, err)}
+		}
+		req.Body = body
+	}
+
+	// Only the path is recorded: the query may carry an API key for
+	// services that take it there.
+	_, span := tracing.Start(ctx, req.Method+" "+req.URL.Path, tracing.KindClient,
+		tracing.String("arr.service", c.spec.Name),
+		tracing.String("http.request.method", req.Method),
+		tracing.String("server.address", req.URL.Host),
+		tracing.String("url.path", req.URL.Path))
+	if n > 0 {
+		span.SetAttrs(tracing.Int("http.request.resend_count", n))
+	}
+	a := c.roundTrip(req, span)
+	span.End(a.err)
+	return a
+}
+
+// roundTrip sends an authorized request and reads the response, recording
+// its latency and status.
+func (c *Client) roundTrip(req *http.Request, span *tracing.Span) attempt {
+	start := time.Now()
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("%s request failed: %s", c.spec.Name, c.redact(err.Error()))
+		metrics.Upstream.Since(start, c.spec.Name, req.Method, "error")
+		var op *net.OpError
+		return attempt{
+			err:    fmt.Errorf("%s request failed: %s", c.spec.Name, c.redact(err.Error())),
+			unsent: errors.As(err, &op) && op.Op == "dial",
+		}
 	}
 	defer func() { _ = resp.Body.Close() }()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading %s response: %w", c.spec.Name, err)
+	if rec, ok := req.Context().Value(statusKey{}).(*atomic.Int32); ok {
+		rec.Store(int32(resp.StatusCode)) // #nosec G115 -- HTTP status codes are three digits
 	}
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("%s returned %d: %s",
+	respBody, err := io.ReadAll(resp.Body)
+	metrics.Upstream.Since(start, c.spec.Name, req.Method, strconv.Itoa(resp.StatusCode))
+	span.SetAttrs(
+		tracing.Int("http.response.status_code", resp.StatusCode),
+		tracing.Int("http.response.body.size", len(respBody)))
+	a := attempt{status: resp.StatusCode, retryAfter: resp.Header.Get("Retry-After")}
+	switch {
+	case err != nil:
+		a.err = fmt.Errorf("reading %s response: %w", c.spec.Name, err)
+	case resp.StatusCode >= 400:
+		a.err = fmt.Errorf("%s returned %d: %s",
 			c.spec.Name, resp.StatusCode, c.redact(strings.TrimSpace(string(respBody))))
+	default:
+		a.body = respBody
 	}
-	return respBody, nil
+	return a
 }
 
-// Get performs a GET request with optional query parameters.
+type statusKey struct{}
+
+// RecordStatus returns a context under which clients note the HTTP status of
+// each response, and a function reporting the most recent one, or 0 when no
+// response has arrived. Clients are shared between calls, so the status lives
+// with the call's context rather than on the client.
+func RecordStatus(ctx context.Context) (context.Context, func() int) {
+	rec := new(atomic.Int32)
+	return context.WithValue(ctx, statusKey{}, rec), func() int { return int(rec.Load()) }
+}
+
+// Get performs a GET request with optional query parameters. Responses from
+// the endpoints in cacheTTLs are served from the client's cache while fresh.
 func (c *Client) Get(ctx context.Context, path string, q ...Query) ([]byte, error) {
-	return c.do(ctx, http.MethodGet, path, nil, first(q))
+	ttl, cacheable := cacheTTLs[path]
+	if !cacheable {
+		return c.do(ctx, http.MethodGet, path, nil, first(q))
+	}
+	key := cacheKey(path, first(q))
+	if body, ok := c.cache.get(ctx, key); ok {
+		return body, nil
+	}
+	body, err := c.do(ctx, http.MethodGet, path, nil, first(q))
+	if err == nil {
+		c.cache.put(path, key, body, ttl)
+	}
+	return body, err
 }
 
 // Post performs a POST request with a JSON body.
@@ -223,11 +462,14 @@ func (c *Client) Delete(ctx context.Context, path string, q ...Query) ([]byte, e
 	return c.do(ctx, http.MethodDelete, path, nil, first(q))
 }
 
-// Ping checks that the instance is reachable and the credentials work.
+// Ping checks that the instance is reachable and the credentials work. It
+// makes a single attempt: a health check should report what it saw.
 func (c *Client) Ping(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	_, err := c.Get(ctx, c.spec.StatusPath)
+	once := *c
+	once.policy.Retries = 0
+	_, err := once.Get(ctx, c.spec.StatusPath)
 	return err
 }
 
@@ -246,7 +488,11 @@ func GetJSON[T any](ctx context.Context, c *Client, path string, q ...Query) (T,
 	if err != nil {
 		return out, err
 	}
-	if err := json.Unmarshal(body, &out); err != nil {
+	_, span := tracing.Start(ctx, "decode "+path, tracing.KindInternal,
+		tracing.String("arr.service", c.spec.Name), tracing.Int("http.response.body.size", len(body)))
+	err = json.Unmarshal(body, &out)
+	span.End(err)
+	if err != nil {
 		return out, fmt.Errorf("decoding %s response from %s: %w", c.spec.Name, path, err)
 	}
 	return out, nil
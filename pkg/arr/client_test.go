@@ -2,11 +2,16 @@ package arr
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // capture records what the fake upstream received.
@@ -171,6 +176,44 @@ func TestBasicAuthCredentialsAreApplied(t *testing.T) {
 	}
 }
 
+// Behind an auth proxy, extra headers and basic credentials travel with the
+// API key rather than replacing it.
+func TestProxyHeadersAndBasicAuthLayerOnTheAPIKey(t *testing.T) {
+	srv, got := fakeService(t, 200, `{}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{
+		APIKey: "key", Username: "proxy", Password: "pw",
+		Headers: map[string]string{"CF-Access-Client-Id": "client-id", "X-Api-Key": "clobbered"},
+	})
+
+	if _, err := c.Get(context.Background(), "/series"); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if v := got.header.Get("X-Api-Key"); v != "key" {
+		t.Errorf("X-Api-Key = %q, want the API key to win over an extra header", v)
+	}
+	if v := got.header.Get("Cf-Access-Client-Id"); v != "client-id" {
+		t.Errorf("CF-Access-Client-Id = %q, want client-id", v)
+	}
+	if user, pass, ok := parseBasic(got.header.Get("Authorization")); !ok || user != "proxy" || pass != "pw" {
+		t.Errorf("basic auth = (%q,%q,%v), want (proxy,pw,true)", user, pass, ok)
+	}
+}
+
+func TestRedactHidesProxyCredentials(t *testing.T) {
+	c := NewClient("http://x", SonarrSpec, Credentials{
+		APIKey: "k", Username: "proxy", Password: "correct-horse-battery",
+		Headers: map[string]string{"CF-Access-Client-Secret": "cf-secret-value"},
+	})
+	encoded := base64.StdEncoding.EncodeToString([]byte("proxy:correct-horse-battery"))
+
+	got := c.redact("denied: secret cf-secret-value, Authorization: Basic " + encoded)
+	for _, secret := range []string{"cf-secret-value", encoded} {
+		if strings.Contains(got, secret) {
+			t.Errorf("redact leaked %q: %q", secret, got)
+		}
+	}
+}
+
 // parseBasic decodes an Authorization header for assertions.
 func parseBasic(h string) (string, string, bool) {
 	r, _ := http.NewRequest(http.MethodGet, "http://x", nil)
@@ -212,3 +255,78 @@ func TestRedactHidesPasswords(t *testing.T) {
 		t.Errorf("redact leaked the password: %q", got)
 	}
 }
+
+func TestRecordStatusReportsTheLastResponse(t *testing.T) {
+	srv, _ := fakeService(t, http.StatusNotFound, `{"message":"gone"}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	ctx, status := RecordStatus(context.Background())
+	if got := status(); got != 0 {
+		t.Errorf("status before any request = %d, want 0", got)
+	}
+	_, _ = c.Get(ctx, "/series/1")
+	if got := status(); got != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", got)
+	}
+}
+
+// A shared client must reuse its connections, including through the copies
+// WithTimeout makes for long-running calls.
+func TestClientReusesConnections(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns = map[string]bool{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns[r.RemoteAddr] = true
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(context.Background(), "/system/status"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if _, err := c.WithTimeout(time.Minute).Get(context.Background(), "/system/status"); err != nil {
+			t.Fatalf("Get with timeout: %v", err)
+		}
+	}
+	if len(conns) != 1 {
+		t.Errorf("six sequential requests used %d connections, want 1", len(conns))
+	}
+}
+
+func TestWithTLSTrustsAPrivateCAAndPresentsAClientCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	plain := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+	if err := plain.Ping(context.Background()); err == nil {
+		t.Fatal("a client without the CA accepted the test server's certificate")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	c := plain.WithTLS(&tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		RootCAs:      roots,
+		Certificates: srv.TLS.Certificates,
+	})
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping with the CA and a client certificate: %v", err)
+	}
+	if same := plain.WithTLS(nil); same != plain {
+		t.Error("WithTLS(nil) copied the client, want it unchanged")
+	}
+}
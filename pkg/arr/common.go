@@ -8,6 +8,14 @@ type QualityProfile struct {
 	Name string `json:"name"`
 }
 
+// MetadataProfile selects which releases Lidarr tracks for an artist, or which
+// books Readarr tracks for an author. Both require one when adding media,
+// alongside the quality profile.
+type MetadataProfile struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
 // RootFolder is a library root path as needed when adding media.
 type RootFolder struct {
 	ID         int    `json:"id"`
@@ -34,6 +42,12 @@ func ListRootFolders(ctx context.Context, c *Client) ([]RootFolder, error) {
 	return GetJSON[[]RootFolder](ctx, c, "/rootfolder")
 }
 
+// ListMetadataProfiles returns the metadata profiles configured on a Lidarr or
+// Readarr instance.
+func ListMetadataProfiles(ctx context.Context, c *Client) ([]MetadataProfile, error) {
+	return GetJSON[[]MetadataProfile](ctx, c, "/metadataprofile")
+}
+
 // GetSystemStatus returns version and identity information for an instance.
 func GetSystemStatus(ctx context.Context, c *Client) (SystemStatus, error) {
 	return GetJSON[SystemStatus](ctx, c, "/system/status")
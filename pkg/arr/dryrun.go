@@ -0,0 +1,193 @@
+package arr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ErrDryRun is what a call made under DryRun amounts to: its requests were
+// recorded in the plan instead of being sent, so the plan is its result.
+var ErrDryRun = errors.New("dry run: request not sent")
+
+// PlannedRequest is a request a dry run held back: the method, the path as it
+// would appear on the wire, base path and query included, and the JSON body.
+type PlannedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   any    `json:"body,omitempty"`
+}
+
+// FieldChange is the current and requested value of one field.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// RecordChange lists the fields a bulk edit would change on one record.
+type RecordChange struct {
+	ID     int                    `json:"id"`
+	Title  string                 `json:"title,omitempty"`
+	Fields map[string]FieldChange `json:"fields"`
+}
+
+// Plan collects what a call made under DryRun would have changed.
+type Plan struct {
+	mu       sync.Mutex
+	requests []PlannedRequest
+	changes  []RecordChange
+}
+
+type planKey struct{}
+
+// DryRun returns a context under which clients send reads as usual but record
+// every other request in the returned plan instead of sending it, answering
+// as if it had succeeded. Reads still go out because lookups and change
+// reports depend on them; nothing that could change the service is sent.
+func DryRun(ctx context.Context) (context.Context, *Plan) {
+	p := &Plan{}
+	return context.WithValue(ctx, planKey{}, p), p
+}
+
+// planFrom returns the plan ctx records into, or nil outside a dry run.
+func planFrom(ctx context.Context) *Plan {
+	p, _ := ctx.Value(planKey{}).(*Plan)
+	return p
+}
+
+// Requests returns the requests recorded so far.
+func (p *Plan) Requests() []PlannedRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.requests)
+}
+
+// Changes returns the records a bulk edit would change, when the tool
+// reports them.
+func (p *Plan) Changes() []RecordChange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.changes)
+}
+
+// hold records a request the client is about to send and reports whether it
+// must be held back, with the response the client returns in its place: a
+// JSON null, which decodes into whatever the caller expects as its zero
+// value. Answering as a success keeps a handler that sends several
+// independent writes going, so the plan lists every one. Reads pass through.
+func (p *Plan) hold(method, path string, body []byte) ([]byte, bool) {
+	if p == nil || method == http.MethodGet {
+		return nil, false
+	}
+	r := PlannedRequest{Method: method, Path: path}
+	if body != nil {
+		// The body was encoded from a value a moment ago, so it decodes.
+		_ = json.Unmarshal(body, &r.Body)
+	}
+	p.mu.Lock()
+	p.requests = append(p.requests, r)
+	p.mu.Unlock()
+	return []byte("null"), true
+}
+
+// planEdits reports, under a dry run, which records a bulk edit to the editor
+// of listPath would change. req is the editor request, whose idKey lists the
+// records; titleKey names the field that titles each record. Outside a dry
+// run it does nothing.
+func planEdits(ctx context.Context, c *Client, listPath, idKey, titleKey string, req any) error {
+	p := planFrom(ctx)
+	if p == nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := remarshal(req, &fields); err != nil {
+		return err
+	}
+	// Compare against the service, not a cached copy that may predate the
+	// last edit.
+	records, err := GetJSON[[]map[string]any](NoCache(ctx), c, listPath)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]map[string]any, len(records))
+	for _, r := range records {
+		if id, ok := r["id"].(float64); ok {
+			byID[int(id)] = r
+		}
+	}
+
+	var ids []int
+	if err := remarshal(fields[idKey], &ids); err != nil {
+		return err
+	}
+	var changes []RecordChange
+	for _, id := range ids {
+		current, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("%s has no record with id %d at %s", c.spec.Name, id, listPath)
+		}
+		change := RecordChange{ID: id, Fields: map[string]FieldChange{}}
+		change.Title, _ = current[titleKey].(string)
+		for name, to := range fields {
+			switch name {
+			case idKey, "moveFiles", "applyTags":
+				continue
+			case "tags":
+				to = applyTags(current["tags"], to, fields["applyTags"])
+			}
+			if from := current[name]; !reflect.DeepEqual(from, to) {
+				change.Fields[name] = FieldChange{From: from, To: to}
+			}
+		}
+		if len(change.Fields) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	p.mu.Lock()
+	p.changes = append(p.changes, changes...)
+	p.mu.Unlock()
+	return nil
+}
+
+// applyTags computes the tags a record ends up with when the editor applies
+// requested to current in mode add, remove or replace. Both lists hold the
+// float64s JSON decodes to, and so does the result, in current's order.
+func applyTags(current, requested, mode any) any {
+	have, _ := current.([]any)
+	want, _ := requested.([]any)
+	switch mode {
+	case "replace":
+		return want
+	case "remove":
+		out := []any{}
+		for _, t := range have {
+			if !slices.Contains(want, t) {
+				out = append(out, t)
+			}
+		}
+		return out
+	default:
+		out := append([]any{}, have...)
+		for _, t := range want {
+			if !slices.Contains(out, t) {
+				out = append(out, t)
+			}
+		}
+		return out
+	}
+}
+
+// remarshal converts v to out by way of JSON.
+func remarshal(v, out any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
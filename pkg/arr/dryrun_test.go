@@ -0,0 +1,111 @@
+package arr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDryRunRecordsMutationsWithoutSendingThem(t *testing.T) {
+	srv, got := fakeService(t, 200, `{}`)
+	c := NewClient(srv.URL+"/sonarr", SonarrSpec, Credentials{APIKey: "k"})
+	ctx, plan := DryRun(context.Background())
+
+	if _, err := c.Get(ctx, "/system/status"); err != nil {
+		t.Fatalf("Get under a dry run returned error: %v", err)
+	}
+	if _, err := c.Post(ctx, "/command", map[string]any{"name": "RssSync"}); err != nil {
+		t.Fatalf("Post under a dry run returned error: %v", err)
+	}
+	if _, err := c.Delete(ctx, "/series/3", Query{"deleteFiles": "true"}); err != nil {
+		t.Fatalf("Delete under a dry run returned error: %v", err)
+	}
+
+	if want := []string{"GET /sonarr/api/v3/system/status"}; !reflect.DeepEqual(got.paths, want) {
+		t.Errorf("sent %v, want only the read", got.paths)
+	}
+	want := []PlannedRequest{
+		{Method: "POST", Path: "/sonarr/api/v3/command", Body: map[string]any{"name": "RssSync"}},
+		{Method: "DELETE", Path: "/sonarr/api/v3/series/3?deleteFiles=true"},
+	}
+	if reqs := plan.Requests(); !reflect.DeepEqual(reqs, want) {
+		t.Errorf("planned %+v, want %+v", reqs, want)
+	}
+}
+
+func TestSonarrEditSeriesReportsWhatADryRunWouldChange(t *testing.T) {
+	srv, got := fakeService(t, 200, `[
+		{"id":1,"title":"Severance","monitored":true,"qualityProfileId":4,"tags":[1]},
+		{"id":2,"title":"Andor","monitored":false,"qualityProfileId":4,"tags":[2]},
+		{"id":3,"title":"Dark","monitored":true,"qualityProfileId":6,"tags":[]}
+	]`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+	ctx, plan := DryRun(context.Background())
+
+	monitored, profile := false, 4
+	_, err := SonarrEditSeries(ctx, c, SeriesEditRequest{
+		SeriesIDs: []int{1, 2}, Monitored: &monitored, QualityProfileID: &profile, Tags: []int{2},
+	})
+	if err != nil {
+		t.Fatalf("SonarrEditSeries under a dry run returned error: %v", err)
+	}
+	if got.method != "GET" {
+		t.Errorf("last request sent = %s %s, want only the read", got.method, got.path)
+	}
+	want := []RecordChange{{ID: 1, Title: "Severance", Fields: map[string]FieldChange{
+		"monitored": {From: true, To: false},
+		"tags":      {From: []any{1.0}, To: []any{1.0, 2.0}},
+	}}}
+	if changes := plan.Changes(); !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+	if reqs := plan.Requests(); len(reqs) != 1 || reqs[0].Path != "/api/v3/series/editor" {
+		t.Errorf("planned %+v, want the PUT to the editor", reqs)
+	}
+}
+
+func TestEditorDryRunRejectsUnknownIDs(t *testing.T) {
+	srv, _ := fakeService(t, 200, `[{"id":1,"artistName":"Low"}]`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+	ctx, plan := DryRun(context.Background())
+
+	_, err := LidarrEditArtists(ctx, c, ArtistEditRequest{ArtistIDs: []int{1, 9}})
+	if err == nil {
+		t.Fatal("err = nil, want the missing artist reported")
+	}
+	if len(plan.Requests()) != 0 {
+		t.Error("the edit was planned despite the unknown id")
+	}
+}
+
+// Each held write answers as a success, so a handler deleting several files
+// plans them all rather than stopping at the first.
+func TestDryRunPlansEveryWriteOfALoop(t *testing.T) {
+	srv, got := fakeService(t, 200, `{}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+	ctx, plan := DryRun(context.Background())
+
+	n, err := deleteFiles(ctx, c, "/episodefile", []int{4, 5, 6}, "episode file")
+	if err != nil || n != 3 {
+		t.Fatalf("deleteFiles = %d, %v, want all three", n, err)
+	}
+	if reqs := plan.Requests(); len(reqs) != 3 || reqs[2].Path != "/api/v3/episodefile/6" {
+		t.Errorf("planned %+v, want all three deletes", reqs)
+	}
+	if len(got.paths) != 0 {
+		t.Errorf("sent %v, want nothing", got.paths)
+	}
+}
+
+func TestApplyTags(t *testing.T) {
+	have, want := []any{1.0, 2.0}, []any{2.0, 3.0}
+	for mode, expect := range map[string][]any{
+		"add":     {1.0, 2.0, 3.0},
+		"remove":  {1.0},
+		"replace": {2.0, 3.0},
+	} {
+		if got := applyTags(have, want, mode); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: tags = %v, want %v", mode, got, expect)
+		}
+	}
+}
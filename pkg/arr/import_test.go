@@ -0,0 +1,146 @@
+package arr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const sonarrCandidatesBody = `[{
+  "path":"/downloads/Show.S01E02/show.s01e02.mkv","size":900,"releaseGroup":"GRP",
+  "downloadId":"ABC123","seasonNumber":1,"releaseType":"singleEpisode","indexerFlags":0,
+  "quality":{"quality":{"id":3,"name":"WEBDL-1080p"},"revision":{"version":1,"real":0}},
+  "languages":[{"id":1,"name":"English"}],
+  "series":{"id":5,"title":"New Girl","overview":"a long synopsis"},
+  "episodes":[{"id":12,"title":"Kryptonite"}],
+  "rejections":[{"reason":"Unable to parse episode","type":"permanent"}]
+}]`
+
+func TestListImportCandidatesProjectsTheProposal(t *testing.T) {
+	srv, got := fakeService(t, 200, sonarrCandidatesBody)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	candidates, err := ListImportCandidates(context.Background(), c, "ABC123", "")
+	if err != nil {
+		t.Fatalf("ListImportCandidates returned error: %v", err)
+	}
+	if got.path != "/api/v3/manualimport" || !contains(got.query, "downloadId=ABC123") {
+		t.Errorf("request = %s?%s, want /api/v3/manualimport?downloadId=ABC123", got.path, got.query)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("candidates = %d, want 1", len(candidates))
+	}
+	cand := candidates[0]
+	if cand.SeriesID != 5 || cand.MediaTitle != "New Girl" || len(cand.EpisodeIDs) != 1 || cand.EpisodeIDs[0] != 12 {
+		t.Errorf("candidate = %+v, want New Girl episode 12", cand)
+	}
+	if cand.Quality != "WEBDL-1080p" || len(cand.Rejections) != 1 {
+		t.Errorf("candidate = %+v, want the quality name and one rejection", cand)
+	}
+	if encoded, _ := json.Marshal(candidates); contains(string(encoded), "synopsis") {
+		t.Errorf("embedded series leaked into the candidate: %s", encoded)
+	}
+}
+
+func TestListImportCandidatesNeedsAScope(t *testing.T) {
+	srv, got := fakeService(t, 200, `[]`)
+	c := NewClient(srv.URL, RadarrSpec, Credentials{APIKey: "k"})
+
+	if _, err := ListImportCandidates(context.Background(), c, "", ""); err == nil {
+		t.Fatal("expected an error with neither a download nor a folder")
+	}
+	if got.path != "" {
+		t.Errorf("upstream contacted at %s for an unscoped scan", got.path)
+	}
+}
+
+// The command must carry the caller's mapping together with the quality and
+// languages from the scan, byte for byte: without them the import fails.
+func TestManualImportEchoesScannedQualityWithTheMapping(t *testing.T) {
+	var commandBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			commandBody, _ = readAll(r)
+			_, _ = w.Write([]byte(`{"id":77,"name":"ManualImport","status":"queued"}`))
+			return
+		}
+		_, _ = w.Write([]byte(sonarrCandidatesBody))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	res, err := ManualImport(context.Background(), c, "ABC123", "", "", []ImportFile{{
+		Path: "/downloads/Show.S01E02/show.s01e02.mkv", SeriesID: 5, EpisodeIDs: []int{13},
+	}})
+	if err != nil {
+		t.Fatalf("ManualImport returned error: %v", err)
+	}
+	if res.ID != 77 {
+		t.Errorf("result = %+v, want command 77", res)
+	}
+	var sent struct {
+		Name       string `json:"name"`
+		ImportMode string `json:"importMode"`
+		Files      []struct {
+			SeriesID   int             `json:"seriesId"`
+			EpisodeIDs []int           `json:"episodeIds"`
+			DownloadID string          `json:"downloadId"`
+			Quality    json.RawMessage `json:"quality"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(commandBody), &sent); err != nil {
+		t.Fatalf("command body is not valid JSON: %v", err)
+	}
+	if sent.Name != "ManualImport" || sent.ImportMode != "auto" || len(sent.Files) != 1 {
+		t.Fatalf("command = %s, want one auto ManualImport file", commandBody)
+	}
+	f := sent.Files[0]
+	if f.SeriesID != 5 || len(f.EpisodeIDs) != 1 || f.EpisodeIDs[0] != 13 || f.DownloadID != "ABC123" {
+		t.Errorf("file = %+v, want the caller's episode 13 for download ABC123", f)
+	}
+	if !contains(string(f.Quality), `"revision"`) {
+		t.Errorf("quality = %s, want the scanned quality including its revision", f.Quality)
+	}
+}
+
+func TestManualImportRejectsUnknownPathsAndIncompleteMappings(t *testing.T) {
+	srv, got := fakeService(t, 200, sonarrCandidatesBody)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	if _, err := ManualImport(context.Background(), c, "ABC123", "", "", []ImportFile{{
+		Path: "/downloads/Show.S01E02/show.s01e02.mkv", SeriesID: 5,
+	}}); err == nil {
+		t.Error("expected an error for a Sonarr mapping without episodes")
+	}
+	if len(got.paths) != 0 {
+		t.Errorf("upstream contacted %v for an incomplete mapping", got.paths)
+	}
+
+	if _, err := ManualImport(context.Background(), c, "ABC123", "", "", []ImportFile{{
+		Path: "/etc/passwd", SeriesID: 5, EpisodeIDs: []int{12},
+	}}); err == nil {
+		t.Error("expected an error for a path the scan did not find")
+	}
+	for _, p := range got.paths {
+		if p == "POST /api/v3/command" {
+			t.Errorf("a command was sent for a path outside the scan: %v", got.paths)
+		}
+	}
+}
+
+func TestManualImportRejectsUnknownImportModes(t *testing.T) {
+	srv, got := fakeService(t, 200, sonarrCandidatesBody)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	if _, err := ManualImport(context.Background(), c, "ABC123", "", "hardlink", []ImportFile{{
+		Path: "/downloads/Show.S01E02/show.s01e02.mkv", SeriesID: 5, EpisodeIDs: []int{12},
+	}}); err == nil {
+		t.Error("expected an error for an unknown import mode")
+	}
+	if len(got.paths) != 0 {
+		t.Errorf("upstream contacted %v for an unknown import mode", got.paths)
+	}
+}
@@ -0,0 +1,270 @@
+package arr
+
+import (
+	"context"
+	"fmt"
+)
+
+// Artist is the trimmed view of a Lidarr artist returned to MCP clients. The
+// upstream resource carries the full MusicBrainz overview, links, images and
+// genres; only identity, state and the album counts survive.
+type Artist struct {
+	ID              int    `json:"id" jsonschema:"Lidarr's internal artist id, used by other tools"`
+	ArtistName      string `json:"artistName"`
+	ForeignArtistID string `json:"foreignArtistId,omitempty" jsonschema:"MusicBrainz artist id, required when adding the artist"`
+	Status          string `json:"status,omitempty" jsonschema:"continuing or ended"`
+	Monitored       bool   `json:"monitored"`
+	AlbumCount      int    `json:"albumCount,omitempty"`
+	TrackFileCount  int    `json:"trackFileCount,omitempty"`
+}
+
+// rawArtist mirrors the upstream Lidarr payload we care about before trimming.
+type rawArtist struct {
+	ID              int    `json:"id"`
+	ArtistName      string `json:"artistName"`
+	ForeignArtistID string `json:"foreignArtistId"`
+	Status          string `json:"status"`
+	Monitored       bool   `json:"monitored"`
+	Statistics      struct {
+		AlbumCount     int `json:"albumCount"`
+		TrackFileCount int `json:"trackFileCount"`
+	} `json:"statistics"`
+}
+
+func (r rawArtist) toArtist() Artist {
+	return Artist{
+		ID: r.ID, ArtistName: r.ArtistName, ForeignArtistID: r.ForeignArtistID,
+		Status: r.Status, Monitored: r.Monitored,
+		AlbumCount: r.Statistics.AlbumCount, TrackFileCount: r.Statistics.TrackFileCount,
+	}
+}
+
+func trimArtists(raw []rawArtist) []Artist {
+	out := make([]Artist, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toArtist())
+	}
+	return out
+}
+
+// Album is the trimmed view of a Lidarr album. Each upstream album embeds its
+// artist resource and every release edition with its media and track counts,
+// so a discography listing is projected down to the album itself.
+type Album struct {
+	ID             int    `json:"id"`
+	ArtistID       int    `json:"artistId"`
+	Title          string `json:"title"`
+	ForeignAlbumID string `json:"foreignAlbumId,omitempty" jsonschema:"MusicBrainz release group id"`
+	AlbumType      string `json:"albumType,omitempty" jsonschema:"Album, EP, Single, ..."`
+	ReleaseDate    string `json:"releaseDate,omitempty"`
+	Monitored      bool   `json:"monitored"`
+	TrackCount     int    `json:"trackCount,omitempty"`
+	TrackFileCount int    `json:"trackFileCount,omitempty" jsonschema:"tracks with a file on disk"`
+}
+
+// rawAlbum mirrors the upstream album before trimming. The embedded artist and
+// releases are absent by design rather than decoded and discarded.
+type rawAlbum struct {
+	ID             int    `json:"id"`
+	ArtistID       int    `json:"artistId"`
+	Title          string `json:"title"`
+	ForeignAlbumID string `json:"foreignAlbumId"`
+	AlbumType      string `json:"albumType"`
+	ReleaseDate    string `json:"releaseDate"`
+	Monitored      bool   `json:"monitored"`
+	Statistics     struct {
+		TrackCount     int `json:"trackCount"`
+		TrackFileCount int `json:"trackFileCount"`
+	} `json:"statistics"`
+}
+
+func (r rawAlbum) toAlbum() Album {
+	return Album{
+		ID: r.ID, ArtistID: r.ArtistID, Title: r.Title, ForeignAlbumID: r.ForeignAlbumID,
+		AlbumType: r.AlbumType, ReleaseDate: r.ReleaseDate, Monitored: r.Monitored,
+		TrackCount: r.Statistics.TrackCount, TrackFileCount: r.Statistics.TrackFileCount,
+	}
+}
+
+func trimAlbums(raw []rawAlbum) []Album {
+	out := make([]Album, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toAlbum())
+	}
+	return out
+}
+
+// LidarrListArtists returns every artist in the library.
+func LidarrListArtists(ctx context.Context, c *Client) ([]Artist, error) {
+	raw, err := GetJSON[[]rawArtist](ctx, c, "/artist")
+	if err != nil {
+		return nil, err
+	}
+	return trimArtists(raw), nil
+}
+
+// LidarrLookupArtists searches MusicBrainz for artists matching term.
+func LidarrLookupArtists(ctx context.Context, c *Client, term string) ([]Artist, error) {
+	raw, err := GetJSON[[]rawArtist](ctx, c, "/artist/lookup", Query{"term": term})
+	if err != nil {
+		return nil, err
+	}
+	return trimArtists(raw), nil
+}
+
+// LidarrListAlbums returns the albums of one artist, or of the whole library
+// when artistID is zero.
+func LidarrListAlbums(ctx context.Context, c *Client, artistID int) ([]Album, error) {
+	var q Query
+	if artistID > 0 {
+		q = Query{"artistId": itoa(artistID)}
+	}
+	raw, err := GetJSON[[]rawAlbum](ctx, c, "/album", q)
+	if err != nil {
+		return nil, err
+	}
+	return trimAlbums(raw), nil
+}
+
+// LidarrLookupAlbums searches MusicBrainz for albums matching term.
+func LidarrLookupAlbums(ctx context.Context, c *Client, term string) ([]Album, error) {
+	raw, err := GetJSON[[]rawAlbum](ctx, c, "/album/lookup", Query{"term": term})
+	if err != nil {
+		return nil, err
+	}
+	return trimAlbums(raw), nil
+}
+
+// AddArtistRequest describes an artist to add to a Lidarr library. Unlike
+// Sonarr and Radarr, Lidarr also requires a metadata profile, which decides
+// which release types (albums, singles, live) are tracked.
+type AddArtistRequest struct {
+	ForeignArtistID   string `json:"foreignArtistId"`
+	ArtistName        string `json:"artistName"`
+	QualityProfileID  int    `json:"qualityProfileId"`
+	MetadataProfileID int    `json:"metadataProfileId"`
+	RootFolderPath    string `json:"rootFolderPath"`
+	Monitored         bool   `json:"monitored"`
+	AddOptions        struct {
+		Monitor                string `json:"monitor,omitempty"`
+		SearchForMissingAlbums bool   `json:"searchForMissingAlbums"`
+	} `json:"addOptions"`
+}
+
+// LidarrAddArtist adds an artist to the library and returns the created record.
+func LidarrAddArtist(ctx context.Context, c *Client, req AddArtistRequest) (Artist, error) {
+	body, err := c.Post(ctx, "/artist", req)
+	if err != nil {
+		return Artist{}, err
+	}
+	var raw rawArtist
+	if err := unmarshal(body, &raw); err != nil {
+		return Artist{}, err
+	}
+	return raw.toArtist(), nil
+}
+
+// LidarrGetArtist returns one artist from the library by its internal id.
+func LidarrGetArtist(ctx context.Context, c *Client, id int) (Artist, error) {
+	raw, err := GetJSON[rawArtist](ctx, c, "/artist/"+itoa(id))
+	if err != nil {
+		return Artist{}, err
+	}
+	return raw.toArtist(), nil
+}
+
+// LidarrDeleteArtist removes an artist, optionally deleting its files.
+func LidarrDeleteArtist(ctx context.Context, c *Client, id int, deleteFiles bool) error {
+	_, err := c.Delete(ctx, "/artist/"+itoa(id), Query{"deleteFiles": btoa(deleteFiles)})
+	return err
+}
+
+// ArtistEditRequest describes a bulk change to one or more artists. As with the
+// Sonarr and Radarr editors, optional fields stay absent rather than being sent
+// as zero values that would overwrite settings the caller never named.
+type ArtistEditRequest struct {
+	ArtistIDs         []int  `json:"artistIds"`
+	Monitored         *bool  `json:"monitored,omitempty"`
+	QualityProfileID  *int   `json:"qualityProfileId,omitempty"`
+	MetadataProfileID *int   `json:"metadataProfileId,omitempty"`
+	RootFolderPath    string `json:"rootFolderPath,omitempty"`
+	MonitorNewItems   string `json:"monitorNewItems,omitempty" jsonschema:"all, none or new"`
+	Tags              []int  `json:"tags,omitempty"`
+	ApplyTags         string `json:"applyTags,omitempty" jsonschema:"add, remove or replace"`
+	MoveFiles         bool   `json:"moveFiles"`
+}
+
+// LidarrEditArtists applies a change to a set of artists at once. Under
+// DryRun it also reports which artists would change.
+func LidarrEditArtists(ctx context.Context, c *Client, req ArtistEditRequest) ([]Artist, error) {
+	if len(req.Tags) > 0 && req.ApplyTags == "" {
+		req.ApplyTags = "add"
+	}
+	if err := planEdits(ctx, c, "/artist", "artistIds", "artistName", req); err != nil {
+		return nil, err
+	}
+	body, err := c.Put(ctx, "/artist/editor", req)
+	if err != nil {
+		return nil, err
+	}
+	var raw []rawArtist
+	if err := unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	return trimArtists(raw), nil
+}
+
+// LidarrMonitorAlbums monitors or unmonitors specific albums.
+func LidarrMonitorAlbums(ctx context.Context, c *Client, albumIDs []int, monitored bool) error {
+	if len(albumIDs) == 0 {
+		return fmt.Errorf("no album ids given; pass the ids from lidarr_list_albums")
+	}
+	_, err := c.Put(ctx, "/album/monitor", struct {
+		AlbumIDs  []int `json:"albumIds"`
+		Monitored bool  `json:"monitored"`
+	}{AlbumIDs: albumIDs, Monitored: monitored})
+	return err
+}
+
+// LidarrWantedMissing returns monitored albums that have been released but have
+// no files, plus the total number missing across the library.
+func LidarrWantedMissing(ctx context.Context, c *Client, pageSize int) ([]Album, int, error) {
+	return lidarrWanted(ctx, c, "/wanted/missing", pageSize)
+}
+
+// LidarrWantedCutoff returns monitored albums whose files are below the quality
+// cutoff, plus the total number across the library.
+func LidarrWantedCutoff(ctx context.Context, c *Client, pageSize int) ([]Album, int, error) {
+	return lidarrWanted(ctx, c, "/wanted/cutoff", pageSize)
+}
+
+// lidarrWanted fetches one of the paged wanted lists. The records are whole
+// album resources, embedded artist included, so they go through the same
+// projection as /album.
+func lidarrWanted(ctx context.Context, c *Client, path string, pageSize int) ([]Album, int, error) {
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	env, err := GetJSON[paged[rawAlbum]](ctx, c, path, Query{"pageSize": itoa(pageSize)})
+	if err != nil {
+		return nil, 0, err
+	}
+	return trimAlbums(env.Records), env.TotalRecords, nil
+}
+
+// LidarrTriggerSearch starts an indexer search for specific albums, or for
+// every monitored album of an artist when no album ids are given.
+func LidarrTriggerSearch(ctx context.Context, c *Client, artistID int, albumIDs []int) (CommandResult, error) {
+	if len(albumIDs) > 0 {
+		return RunCommand(ctx, c, "AlbumSearch", map[string]any{"albumIds": albumIDs})
+	}
+	if artistID <= 0 {
+		return CommandResult{}, fmt.Errorf("give albumIds or an artistId; pass ids from lidarr_list_albums or lidarr_list_artists")
+	}
+	return RunCommand(ctx, c, "ArtistSearch", map[string]any{"artistId": artistID})
+}
+
+// LidarrRefreshArtist rescans one artist's metadata and files.
+func LidarrRefreshArtist(ctx context.Context, c *Client, artistID int) (CommandResult, error) {
+	return RunCommand(ctx, c, "RefreshArtist", map[string]any{"artistId": artistID})
+}
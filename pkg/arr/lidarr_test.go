@@ -0,0 +1,156 @@
+package arr
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// Lidarr serves /api/v1 like Prowlarr, not the /api/v3 of Sonarr and Radarr.
+func TestLidarrUsesV1BasePath(t *testing.T) {
+	srv, got := fakeService(t, 200, `[]`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	if _, err := LidarrListArtists(context.Background(), c); err != nil {
+		t.Fatalf("LidarrListArtists returned error: %v", err)
+	}
+	if got.path != "/api/v1/artist" {
+		t.Errorf("path = %q, want /api/v1/artist", got.path)
+	}
+}
+
+func TestLidarrListArtistsProjectsStatistics(t *testing.T) {
+	srv, _ := fakeService(t, 200, `[{
+	  "id":3,"artistName":"Radiohead","foreignArtistId":"a74b1b7f","status":"continuing",
+	  "monitored":true,"overview":"a long biography","images":[{"url":"https://x/poster.jpg"}],
+	  "statistics":{"albumCount":9,"trackFileCount":101}
+	}]`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	artists, err := LidarrListArtists(context.Background(), c)
+	if err != nil {
+		t.Fatalf("LidarrListArtists returned error: %v", err)
+	}
+	if len(artists) != 1 {
+		t.Fatalf("artists = %d, want 1", len(artists))
+	}
+	a := artists[0]
+	if a.ArtistName != "Radiohead" || a.AlbumCount != 9 || a.TrackFileCount != 101 {
+		t.Errorf("artist = %+v, want Radiohead with 9 albums and 101 files", a)
+	}
+	encoded, _ := json.Marshal(artists)
+	for _, unwanted := range []string{"biography", "poster.jpg"} {
+		if contains(string(encoded), unwanted) {
+			t.Errorf("artist projection leaked %q: %s", unwanted, encoded)
+		}
+	}
+}
+
+func TestLidarrListAlbumsFiltersByArtist(t *testing.T) {
+	srv, got := fakeService(t, 200, `[{"id":7,"artistId":3,"title":"OK Computer","albumType":"Album",
+	  "statistics":{"trackCount":12,"trackFileCount":12},"artist":{"artistName":"embedded"}}]`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	albums, err := LidarrListAlbums(context.Background(), c, 3)
+	if err != nil {
+		t.Fatalf("LidarrListAlbums returned error: %v", err)
+	}
+	if got.path != "/api/v1/album" || !contains(got.query, "artistId=3") {
+		t.Errorf("request = %s?%s, want /api/v1/album?artistId=3", got.path, got.query)
+	}
+	if len(albums) != 1 || albums[0].TrackFileCount != 12 {
+		t.Fatalf("albums = %+v, want OK Computer with 12 files", albums)
+	}
+	if encoded, _ := json.Marshal(albums); contains(string(encoded), "embedded") {
+		t.Errorf("embedded artist leaked into the album listing: %s", encoded)
+	}
+}
+
+func TestLidarrAddArtistSendsMetadataProfile(t *testing.T) {
+	srv, got := fakeService(t, 201, `{"id":11,"artistName":"Portishead"}`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	req := AddArtistRequest{
+		ForeignArtistID: "8f6bd1e4", ArtistName: "Portishead",
+		QualityProfileID: 1, MetadataProfileID: 2, RootFolderPath: "/music", Monitored: true,
+	}
+	artist, err := LidarrAddArtist(context.Background(), c, req)
+	if err != nil {
+		t.Fatalf("LidarrAddArtist returned error: %v", err)
+	}
+	if got.method != "POST" || got.path != "/api/v1/artist" {
+		t.Errorf("request = %s %s, want POST /api/v1/artist", got.method, got.path)
+	}
+	if !contains(got.body, `"metadataProfileId":2`) {
+		t.Errorf("body = %s, want metadataProfileId 2", got.body)
+	}
+	if artist.ID != 11 {
+		t.Errorf("artist = %+v, want id 11", artist)
+	}
+}
+
+func TestLidarrWantedMissingReportsTheTotal(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"totalRecords":88,"records":[{"id":1,"title":"Kid A"}]}`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	albums, total, err := LidarrWantedMissing(context.Background(), c, 1)
+	if err != nil {
+		t.Fatalf("LidarrWantedMissing returned error: %v", err)
+	}
+	if got.path != "/api/v1/wanted/missing" {
+		t.Errorf("path = %q, want /api/v1/wanted/missing", got.path)
+	}
+	if total != 88 || len(albums) != 1 {
+		t.Errorf("albums = %+v total = %d, want one of 88", albums, total)
+	}
+}
+
+func TestLidarrTriggerSearchPrefersAlbums(t *testing.T) {
+	srv, got := fakeService(t, 201, `{"id":1,"name":"AlbumSearch"}`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	if _, err := LidarrTriggerSearch(context.Background(), c, 3, []int{7, 8}); err != nil {
+		t.Fatalf("LidarrTriggerSearch returned error: %v", err)
+	}
+	if !contains(got.body, `"name":"AlbumSearch"`) || contains(got.body, "artistId") {
+		t.Errorf("body = %s, want an AlbumSearch without the artist", got.body)
+	}
+}
+
+func TestLidarrTriggerSearchNeedsAScope(t *testing.T) {
+	srv, got := fakeService(t, 201, `{}`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	if _, err := LidarrTriggerSearch(context.Background(), c, 0, nil); err == nil {
+		t.Fatal("expected an error with neither an artist nor albums")
+	}
+	if got.path != "" {
+		t.Errorf("upstream contacted at %s for an empty search", got.path)
+	}
+}
+
+func TestListTagDetailsCountsLidarrArtists(t *testing.T) {
+	srv, _ := fakeService(t, 200, `[{"id":1,"label":"vinyl","artistIds":[1,2]}]`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	details, err := ListTagDetails(context.Background(), c)
+	if err != nil {
+		t.Fatalf("ListTagDetails returned error: %v", err)
+	}
+	if len(details) != 1 || details[0].MediaCount != 2 {
+		t.Errorf("details = %+v, want mediaCount 2", details)
+	}
+}
+
+func TestLidarrGetArtistFetchesOneArtist(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"id":4,"artistName":"Low","statistics":{"albumCount":12}}`)
+	c := NewClient(srv.URL, LidarrSpec, Credentials{APIKey: "k"})
+
+	artist, err := LidarrGetArtist(context.Background(), c, 4)
+	if err != nil {
+		t.Fatalf("LidarrGetArtist returned error: %v", err)
+	}
+	if got.path != "/api/v1/artist/4" || artist.ArtistName != "Low" || artist.AlbumCount != 12 {
+		t.Errorf("GET %s gave %+v, want Low with 12 albums", got.path, artist)
+	}
+}
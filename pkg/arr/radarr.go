@@ -49,6 +49,15 @@ func RadarrListMovies(ctx context.Context, c *Client) ([]Movie, error) {
 	return trimMovies(raw), nil
 }
 
+// RadarrGetMovie returns one movie from the library by its internal id.
+func RadarrGetMovie(ctx context.Context, c *Client, id int) (Movie, error) {
+	raw, err := GetJSON[rawMovie](ctx, c, "/movie/"+itoa(id))
+	if err != nil {
+		return Movie{}, err
+	}
+	return raw.toMovie(), nil
+}
+
 // RadarrLookupMovies searches for movies matching term.
 func RadarrLookupMovies(ctx context.Context, c *Client, term string) ([]Movie, error) {
 	raw, err := GetJSON[[]rawMovie](ctx, c, "/movie/lookup", Query{"term": term})
@@ -104,11 +113,15 @@ type MovieEditRequest struct {
 	MoveFiles           bool   `json:"moveFiles"`
 }
 
-// RadarrEditMovies applies a change to a set of movies at once.
+// RadarrEditMovies applies a change to a set of movies at once. Under DryRun
+// it also reports which movies would change.
 func RadarrEditMovies(ctx context.Context, c *Client, req MovieEditRequest) ([]Movie, error) {
 	if len(req.Tags) > 0 && req.ApplyTags == "" {
 		req.ApplyTags = "add"
 	}
+	if err := planEdits(ctx, c, "/movie", "movieIds", "title", req); err != nil {
+		return nil, err
+	}
 	body, err := c.Put(ctx, "/movie/editor", req)
 	if err != nil {
 		return nil, err
@@ -229,3 +242,11 @@ func RadarrListCollections(ctx context.Context, c *Client) ([]Collection, error)
 	}
 	return out, nil
 }
+
+// RadarrListReleases runs an interactive search for one movie.
+func RadarrListReleases(ctx context.Context, c *Client, movieID int, limit int) ([]Release, int, error) {
+	if movieID <= 0 {
+		return nil, 0, fmt.Errorf("movieId is required; take it from radarr_list_movies")
+	}
+	return listReleases(ctx, c, Query{"movieId": itoa(movieID)}, limit)
+}
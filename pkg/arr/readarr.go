@@ -0,0 +1,198 @@
+package arr
+
+import (
+	"context"
+	"fmt"
+)
+
+// Author is the trimmed view of a Readarr author returned to MCP clients. The
+// upstream resource carries the Goodreads overview, links, images and ratings;
+// only identity, state and the book counts survive.
+type Author struct {
+	ID              int    `json:"id" jsonschema:"Readarr's internal author id, used by other tools"`
+	AuthorName      string `json:"authorName"`
+	ForeignAuthorID string `json:"foreignAuthorId,omitempty" jsonschema:"metadata author id, required when adding the author"`
+	Status          string `json:"status,omitempty" jsonschema:"continuing or ended"`
+	Monitored       bool   `json:"monitored"`
+	BookCount       int    `json:"bookCount,omitempty"`
+	BookFileCount   int    `json:"bookFileCount,omitempty"`
+}
+
+// rawAuthor mirrors the upstream Readarr payload we care about before trimming.
+type rawAuthor struct {
+	ID              int    `json:"id"`
+	AuthorName      string `json:"authorName"`
+	ForeignAuthorID string `json:"foreignAuthorId"`
+	Status          string `json:"status"`
+	Monitored       bool   `json:"monitored"`
+	Statistics      struct {
+		BookCount     int `json:"bookCount"`
+		BookFileCount int `json:"bookFileCount"`
+	} `json:"statistics"`
+}
+
+func (r rawAuthor) toAuthor() Author {
+	return Author{
+		ID: r.ID, AuthorName: r.AuthorName, ForeignAuthorID: r.ForeignAuthorID,
+		Status: r.Status, Monitored: r.Monitored,
+		BookCount: r.Statistics.BookCount, BookFileCount: r.Statistics.BookFileCount,
+	}
+}
+
+func trimAuthors(raw []rawAuthor) []Author {
+	out := make([]Author, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toAuthor())
+	}
+	return out
+}
+
+// Book is the trimmed view of a Readarr book. Upstream books embed their author
+// and every edition with its own overview and cover art, so a bibliography is
+// projected down to the book itself.
+type Book struct {
+	ID            int    `json:"id"`
+	AuthorID      int    `json:"authorId"`
+	Title         string `json:"title"`
+	ForeignBookID string `json:"foreignBookId,omitempty"`
+	ReleaseDate   string `json:"releaseDate,omitempty"`
+	Monitored     bool   `json:"monitored"`
+	BookFileCount int    `json:"bookFileCount,omitempty" jsonschema:"files on disk; 0 means the book is missing"`
+}
+
+// rawBook mirrors the upstream book before trimming. The embedded author and
+// editions are absent by design rather than decoded and discarded.
+type rawBook struct {
+	ID            int    `json:"id"`
+	AuthorID      int    `json:"authorId"`
+	Title         string `json:"title"`
+	ForeignBookID string `json:"foreignBookId"`
+	ReleaseDate   string `json:"releaseDate"`
+	Monitored     bool   `json:"monitored"`
+	Statistics    struct {
+		BookFileCount int `json:"bookFileCount"`
+	} `json:"statistics"`
+}
+
+func (r rawBook) toBook() Book {
+	return Book{
+		ID: r.ID, AuthorID: r.AuthorID, Title: r.Title, ForeignBookID: r.ForeignBookID,
+		ReleaseDate: r.ReleaseDate, Monitored: r.Monitored,
+		BookFileCount: r.Statistics.BookFileCount,
+	}
+}
+
+func trimBooks(raw []rawBook) []Book {
+	out := make([]Book, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toBook())
+	}
+	return out
+}
+
+// ReadarrListAuthors returns every author in the library.
+func ReadarrListAuthors(ctx context.Context, c *Client) ([]Author, error) {
+	raw, err := GetJSON[[]rawAuthor](ctx, c, "/author")
+	if err != nil {
+		return nil, err
+	}
+	return trimAuthors(raw), nil
+}
+
+// ReadarrLookupAuthors searches the metadata source for authors matching term.
+func ReadarrLookupAuthors(ctx context.Context, c *Client, term string) ([]Author, error) {
+	raw, err := GetJSON[[]rawAuthor](ctx, c, "/author/lookup", Query{"term": term})
+	if err != nil {
+		return nil, err
+	}
+	return trimAuthors(raw), nil
+}
+
+// ReadarrListBooks returns the books of one author, or of the whole library
+// when authorID is zero.
+func ReadarrListBooks(ctx context.Context, c *Client, authorID int) ([]Book, error) {
+	var q Query
+	if authorID > 0 {
+		q = Query{"authorId": itoa(authorID)}
+	}
+	raw, err := GetJSON[[]rawBook](ctx, c, "/book", q)
+	if err != nil {
+		return nil, err
+	}
+	return trimBooks(raw), nil
+}
+
+// ReadarrLookupBooks searches the metadata source for books matching term.
+func ReadarrLookupBooks(ctx context.Context, c *Client, term string) ([]Book, error) {
+	raw, err := GetJSON[[]rawBook](ctx, c, "/book/lookup", Query{"term": term})
+	if err != nil {
+		return nil, err
+	}
+	return trimBooks(raw), nil
+}
+
+// AddAuthorRequest describes an author to add to a Readarr library. Like
+// Lidarr, Readarr requires a metadata profile alongside the quality profile.
+type AddAuthorRequest struct {
+	ForeignAuthorID   string `json:"foreignAuthorId"`
+	AuthorName        string `json:"authorName"`
+	QualityProfileID  int    `json:"qualityProfileId"`
+	MetadataProfileID int    `json:"metadataProfileId"`
+	RootFolderPath    string `json:"rootFolderPath"`
+	Monitored         bool   `json:"monitored"`
+	AddOptions        struct {
+		Monitor               string `json:"monitor,omitempty"`
+		SearchForMissingBooks bool   `json:"searchForMissingBooks"`
+	} `json:"addOptions"`
+}
+
+// ReadarrAddAuthor adds an author to the library and returns the created record.
+func ReadarrAddAuthor(ctx context.Context, c *Client, req AddAuthorRequest) (Author, error) {
+	body, err := c.Post(ctx, "/author", req)
+	if err != nil {
+		return Author{}, err
+	}
+	var raw rawAuthor
+	if err := unmarshal(body, &raw); err != nil {
+		return Author{}, err
+	}
+	return raw.toAuthor(), nil
+}
+
+// ReadarrMonitorBooks monitors or unmonitors specific books.
+func ReadarrMonitorBooks(ctx context.Context, c *Client, bookIDs []int, monitored bool) error {
+	if len(bookIDs) == 0 {
+		return fmt.Errorf("no book ids given; pass the ids from readarr_list_books")
+	}
+	_, err := c.Put(ctx, "/book/monitor", struct {
+		BookIDs   []int `json:"bookIds"`
+		Monitored bool  `json:"monitored"`
+	}{BookIDs: bookIDs, Monitored: monitored})
+	return err
+}
+
+// ReadarrWantedMissing returns monitored books that have been released but have
+// no file, plus the total number missing across the library. The records are
+// whole book resources, so they go through the same projection as /book.
+func ReadarrWantedMissing(ctx context.Context, c *Client, pageSize int) ([]Book, int, error) {
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	env, err := GetJSON[paged[rawBook]](ctx, c, "/wanted/missing", Query{"pageSize": itoa(pageSize)})
+	if err != nil {
+		return nil, 0, err
+	}
+	return trimBooks(env.Records), env.TotalRecords, nil
+}
+
+// ReadarrTriggerSearch starts an indexer search for specific books, or for
+// every monitored book of an author when no book ids are given.
+func ReadarrTriggerSearch(ctx context.Context, c *Client, authorID int, bookIDs []int) (CommandResult, error) {
+	if len(bookIDs) > 0 {
+		return RunCommand(ctx, c, "BookSearch", map[string]any{"bookIds": bookIDs})
+	}
+	if authorID <= 0 {
+		return CommandResult{}, fmt.Errorf("give bookIds or an authorId; pass ids from readarr_list_books or readarr_list_authors")
+	}
+	return RunCommand(ctx, c, "AuthorSearch", map[string]any{"authorId": authorID})
+}
@@ -0,0 +1,131 @@
+package arr
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestReadarrListAuthorsProjectsStatistics(t *testing.T) {
+	srv, got := fakeService(t, 200, `[{
+	  "id":4,"authorName":"Ursula K. Le Guin","foreignAuthorId":"874602","status":"ended",
+	  "monitored":true,"overview":"a long biography","images":[{"url":"https://x/portrait.jpg"}],
+	  "statistics":{"bookCount":23,"bookFileCount":17}
+	}]`)
+	c := NewClient(srv.URL, ReadarrSpec, Credentials{APIKey: "k"})
+
+	authors, err := ReadarrListAuthors(context.Background(), c)
+	if err != nil {
+		t.Fatalf("ReadarrListAuthors returned error: %v", err)
+	}
+	if got.path != "/api/v1/author" {
+		t.Errorf("path = %q, want /api/v1/author", got.path)
+	}
+	if len(authors) != 1 || authors[0].BookCount != 23 || authors[0].BookFileCount != 17 {
+		t.Fatalf("authors = %+v, want Le Guin with 23 books and 17 files", authors)
+	}
+	encoded, _ := json.Marshal(authors)
+	for _, unwanted := range []string{"biography", "portrait.jpg"} {
+		if contains(string(encoded), unwanted) {
+			t.Errorf("author projection leaked %q: %s", unwanted, encoded)
+		}
+	}
+}
+
+func TestReadarrListBooksFiltersByAuthor(t *testing.T) {
+	srv, got := fakeService(t, 200, `[{"id":9,"authorId":4,"title":"The Dispossessed",
+	  "statistics":{"bookFileCount":1},"author":{"authorName":"embedded"},
+	  "editions":[{"overview":"edition blurb"}]}]`)
+	c := NewClient(srv.URL, ReadarrSpec, Credentials{APIKey: "k"})
+
+	books, err := ReadarrListBooks(context.Background(), c, 4)
+	if err != nil {
+		t.Fatalf("ReadarrListBooks returned error: %v", err)
+	}
+	if got.path != "/api/v1/book" || !contains(got.query, "authorId=4") {
+		t.Errorf("request = %s?%s, want /api/v1/book?authorId=4", got.path, got.query)
+	}
+	if len(books) != 1 || books[0].BookFileCount != 1 {
+		t.Fatalf("books = %+v, want The Dispossessed with one file", books)
+	}
+	encoded, _ := json.Marshal(books)
+	for _, unwanted := range []string{"embedded", "edition blurb"} {
+		if contains(string(encoded), unwanted) {
+			t.Errorf("book projection leaked %q: %s", unwanted, encoded)
+		}
+	}
+}
+
+func TestReadarrAddAuthorSendsMetadataProfile(t *testing.T) {
+	srv, got := fakeService(t, 201, `{"id":12,"authorName":"Octavia E. Butler"}`)
+	c := NewClient(srv.URL, ReadarrSpec, Credentials{APIKey: "k"})
+
+	req := AddAuthorRequest{
+		ForeignAuthorID: "29535", AuthorName: "Octavia E. Butler",
+		QualityProfileID: 1, MetadataProfileID: 3, RootFolderPath: "/books", Monitored: true,
+	}
+	req.AddOptions.SearchForMissingBooks = true
+	author, err := ReadarrAddAuthor(context.Background(), c, req)
+	if err != nil {
+		t.Fatalf("ReadarrAddAuthor returned error: %v", err)
+	}
+	if got.method != "POST" || got.path != "/api/v1/author" {
+		t.Errorf("request = %s %s, want POST /api/v1/author", got.method, got.path)
+	}
+	for _, want := range []string{`"metadataProfileId":3`, `"searchForMissingBooks":true`} {
+		if !contains(got.body, want) {
+			t.Errorf("body = %s, want %s", got.body, want)
+		}
+	}
+	if author.ID != 12 {
+		t.Errorf("author = %+v, want id 12", author)
+	}
+}
+
+func TestReadarrMonitorBooksRejectsEmptySelection(t *testing.T) {
+	srv, got := fakeService(t, 202, `{}`)
+	c := NewClient(srv.URL, ReadarrSpec, Credentials{APIKey: "k"})
+
+	if err := ReadarrMonitorBooks(context.Background(), c, nil, true); err == nil {
+		t.Fatal("expected an error with no book ids")
+	}
+	if got.path != "" {
+		t.Errorf("upstream contacted at %s for an empty selection", got.path)
+	}
+
+	if err := ReadarrMonitorBooks(context.Background(), c, []int{5, 6}, false); err != nil {
+		t.Fatalf("ReadarrMonitorBooks returned error: %v", err)
+	}
+	if got.method != "PUT" || got.path != "/api/v1/book/monitor" || !contains(got.body, `"bookIds":[5,6]`) {
+		t.Errorf("request = %s %s %s, want PUT /api/v1/book/monitor with both ids", got.method, got.path, got.body)
+	}
+}
+
+func TestReadarrWantedMissingReportsTheTotal(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"totalRecords":31,"records":[{"id":2,"title":"Kindred"}]}`)
+	c := NewClient(srv.URL, ReadarrSpec, Credentials{APIKey: "k"})
+
+	books, total, err := ReadarrWantedMissing(context.Background(), c, 1)
+	if err != nil {
+		t.Fatalf("ReadarrWantedMissing returned error: %v", err)
+	}
+	if got.path != "/api/v1/wanted/missing" {
+		t.Errorf("path = %q, want /api/v1/wanted/missing", got.path)
+	}
+	if total != 31 || len(books) != 1 {
+		t.Errorf("books = %+v total = %d, want one of 31", books, total)
+	}
+}
+
+func TestListTagDetailsCountsReadarrAuthors(t *testing.T) {
+	srv, _ := fakeService(t, 200, `[{"id":1,"label":"audiobook","authorIds":[1,2,3]}]`)
+	c := NewClient(srv.URL, ReadarrSpec, Credentials{APIKey: "k"})
+
+	details, err := ListTagDetails(context.Background(), c)
+	if err != nil {
+		t.Fatalf("ListTagDetails returned error: %v", err)
+	}
+	if len(details) != 1 || details[0].MediaCount != 3 {
+		t.Errorf("details = %+v, want mediaCount 3", details)
+	}
+}
@@ -0,0 +1,112 @@
+package arr
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+const releasesBody = `[
+  {"guid":"g1","indexerId":2,"indexer":"NZBgeek","title":"Show.S01E01.1080p.WEB-DL",
+   "quality":{"quality":{"name":"WEBDL-1080p"},"revision":{"version":1}},
+   "customFormatScore":150,"size":1500000000,"protocol":"usenet","age":3,
+   "approved":true,"rejections":[],"downloadUrl":"https://idx/get?apikey=secret"},
+  {"guid":"g2","indexerId":5,"indexer":"Tracker","title":"Show.S01E01.720p.HDTV",
+   "quality":{"quality":{"name":"HDTV-720p"}},"customFormatScore":-20,"size":700000000,
+   "protocol":"torrent","seeders":14,"leechers":2,"age":40,"approved":false,
+   "rejections":["Quality not wanted in profile"]},
+  {"guid":"g3","indexerId":5,"title":"Show.S01E01.480p","approved":false,
+   "rejections":[{"reason":"Not enough seeders","type":"permanent"}]}
+]`
+
+func TestSonarrListReleasesProjectsCandidates(t *testing.T) {
+	srv, got := fakeService(t, 200, releasesBody)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	releases, total, err := SonarrListReleases(context.Background(), c, 0, nil, 42, 0)
+	if err != nil {
+		t.Fatalf("SonarrListReleases returned error: %v", err)
+	}
+	if got.path != "/api/v3/release" || got.query != "episodeId=42" {
+		t.Errorf("request = %s?%s, want /api/v3/release?episodeId=42", got.path, got.query)
+	}
+	if total != 3 || len(releases) != 3 {
+		t.Fatalf("releases = %d total = %d, want 3 of 3", len(releases), total)
+	}
+	first, second := releases[0], releases[1]
+	if first.Quality != "WEBDL-1080p" || first.CustomFormatScore != 150 || first.Seeders != nil {
+		t.Errorf("first = %+v, want WEBDL-1080p scoring 150 with no seeders", first)
+	}
+	if second.Seeders == nil || *second.Seeders != 14 || len(second.Rejections) != 1 {
+		t.Errorf("second = %+v, want 14 seeders and one rejection", second)
+	}
+	if encoded, _ := json.Marshal(releases); contains(string(encoded), "apikey=secret") {
+		t.Errorf("release projection leaked the indexer download URL: %s", encoded)
+	}
+}
+
+// Newer builds send rejections as objects; only the reason should survive.
+func TestReleaseRejectionsAcceptBothShapes(t *testing.T) {
+	srv, _ := fakeService(t, 200, releasesBody)
+	c := NewClient(srv.URL, RadarrSpec, Credentials{APIKey: "k"})
+
+	releases, _, err := RadarrListReleases(context.Background(), c, 7, 0)
+	if err != nil {
+		t.Fatalf("RadarrListReleases returned error: %v", err)
+	}
+	if got := releases[2].Rejections; len(got) != 1 || got[0] != "Not enough seeders" {
+		t.Errorf("rejections = %v, want the reason from the object form", got)
+	}
+}
+
+func TestListReleasesLimitKeepsTheTotal(t *testing.T) {
+	srv, got := fakeService(t, 200, releasesBody)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	season := 0
+	releases, total, err := SonarrListReleases(context.Background(), c, 9, &season, 0, 1)
+	if err != nil {
+		t.Fatalf("SonarrListReleases returned error: %v", err)
+	}
+	if !contains(got.query, "seriesId=9") || !contains(got.query, "seasonNumber=0") {
+		t.Errorf("query = %q, want seriesId=9 and seasonNumber=0", got.query)
+	}
+	if len(releases) != 1 || releases[0].GUID != "g1" || total != 3 {
+		t.Errorf("releases = %+v total = %d, want only the top candidate of 3", releases, total)
+	}
+}
+
+func TestSonarrListReleasesNeedsAScope(t *testing.T) {
+	srv, got := fakeService(t, 200, `[]`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	if _, _, err := SonarrListReleases(context.Background(), c, 9, nil, 0, 0); err == nil {
+		t.Fatal("expected an error for a series with no season or episode")
+	}
+	if got.path != "" {
+		t.Errorf("upstream contacted at %s for an unscoped search", got.path)
+	}
+}
+
+func TestGrabReleasePostsGUIDAndIndexer(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"guid":"g2","indexerId":5,"title":"Show.S01E01.720p.HDTV"}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	release, err := GrabRelease(context.Background(), c, "g2", 5)
+	if err != nil {
+		t.Fatalf("GrabRelease returned error: %v", err)
+	}
+	if got.method != "POST" || got.path != "/api/v3/release" {
+		t.Errorf("request = %s %s, want POST /api/v3/release", got.method, got.path)
+	}
+	if !contains(got.body, `"guid":"g2"`) || !contains(got.body, `"indexerId":5`) {
+		t.Errorf("body = %s, want guid g2 and indexerId 5", got.body)
+	}
+	if release.Title != "Show.S01E01.720p.HDTV" {
+		t.Errorf("release = %+v, want the grabbed title back", release)
+	}
+
+	if _, err := GrabRelease(context.Background(), c, "", 5); err == nil {
+		t.Error("expected an error for a missing guid")
+	}
+}
@@ -0,0 +1,206 @@
+package arr
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is wrapped by errors for calls refused without contacting
+// the instance, because its circuit breaker is open.
+var ErrUnavailable = errors.New("instance unavailable")
+
+// RetryPolicy decides how a client retries requests that failed transiently
+// and when an instance that keeps failing is cut off for a while.
+type RetryPolicy struct {
+	// Retries is how many times a request that failed transiently is repeated.
+	// Only reads and PUTs are repeated after the service may have seen them.
+	Retries int
+	// RetryBaseDelay is the backoff before the first retry. It doubles for
+	// each retry after that, up to RetryMaxDelay, with full jitter applied.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the backoff, and any Retry-After the service asks
+	// for: a longer wait gives up instead.
+	RetryMaxDelay time.Duration
+	// BreakerThreshold is how many calls in a row must fail before the
+	// instance is reported unavailable without being contacted; 0 disables it.
+	BreakerThreshold int
+	// BreakerCooldown is how long calls fail fast before one is let through
+	// to test whether the instance has recovered.
+	BreakerCooldown time.Duration
+}
+
+// DefaultRetryPolicy is the policy a new client follows.
+var DefaultRetryPolicy = RetryPolicy{
+	Retries:          2,
+	RetryBaseDelay:   250 * time.Millisecond,
+	RetryMaxDelay:    5 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// attempt is the outcome of sending a request once.
+type attempt struct {
+	body []byte
+	err  error
+	// status is the response code, or 0 when no response arrived.
+	status     int
+	retryAfter string
+	// unsent marks a connection that could not be opened: the service never
+	// saw the request, so repeating it is safe whatever the method.
+	unsent bool
+}
+
+// retryable reports whether a failed attempt is worth repeating. Reads and
+// PUTs, which in these APIs replace a resource with the values sent, are
+// repeated after gateway errors and dropped connections. Other writes are
+// repeated only when the service provably did not act on them: the connection
+// never opened, or it answered 429 Too Many Requests. Re-sending a POST to
+// /command after a 502 could start a second search or a second import.
+func (a attempt) retryable(method string) bool {
+	if a.err == nil {
+		return false
+	}
+	if a.unsent || a.status == http.StatusTooManyRequests {
+		return true
+	}
+	if method != http.MethodGet && method != http.MethodPut {
+		return false
+	}
+	switch a.status {
+	case 0, http.StatusRequestTimeout, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// healthy reports whether the attempt shows the instance answering. Errors
+// the service chose to return, a 404 or a validation failure, say nothing
+// against it; no response, or a proxy reporting it down, does.
+func (a attempt) healthy() bool {
+	switch a.status {
+	case 0:
+		return a.err == nil
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return false
+	}
+	return true
+}
+
+// backoff returns how long to wait before retry n (0 for the first), or false
+// when the service asked for a longer pause than the policy allows. Delays
+// are fully jittered so clients that failed together do not retry together.
+func (c *Client) backoff(n int, retryAfter string) (time.Duration, bool) {
+	ceiling := c.policy.RetryBaseDelay << n
+	if ceiling > c.policy.RetryMaxDelay || ceiling <= 0 {
+		ceiling = c.policy.RetryMaxDelay
+	}
+	var d time.Duration
+	if ceiling > 0 {
+		d = rand.N(ceiling) // #nosec G404 -- jitter needs no cryptographic randomness
+	}
+	if wait, ok := parseRetryAfter(retryAfter); ok {
+		if wait > c.policy.RetryMaxDelay {
+			return 0, false
+		}
+		d = max(d, wait)
+	}
+	return d, true
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done, reporting whether the full wait
+// elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// breaker stops calls to an instance after threshold consecutive failures.
+// While open it refuses calls for the cooldown, then lets a single probe
+// through: success closes it, failure restarts the cooldown. A nil breaker,
+// or one with a zero threshold, always allows.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may go ahead. When it may not, it returns how
+// long remains until the next probe.
+func (b *breaker) allow() (time.Duration, bool) {
+	if b == nil || b.threshold <= 0 {
+		return 0, true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return 0, true
+	}
+	if wait := time.Until(b.openUntil); wait > 0 || b.probing {
+		return max(wait, 0), false
+	}
+	b.probing = true
+	return 0, true
+}
+
+// record notes the outcome of an allowed call.
+func (b *breaker) record(healthy bool) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if healthy {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// release ends an allowed call that proved nothing either way, such as one
+// the caller cancelled, so a probe does not hold the breaker half-open.
+func (b *breaker) release() {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
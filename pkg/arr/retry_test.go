@@ -0,0 +1,233 @@
+package arr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetries retries promptly so tests do not wait on real backoff.
+var fastRetries = RetryPolicy{
+	Retries: 2, RetryBaseDelay: time.Millisecond, RetryMaxDelay: 50 * time.Millisecond,
+}
+
+// flakyService fails the first failures requests with status, then answers
+// 200, and counts every request it receives.
+func flakyService(t *testing.T, failures int, status int, header map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if int(hits.Add(1)) <= failures {
+			for k, v := range header {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestGetIsRetriedAfterGatewayErrors(t *testing.T) {
+	srv, hits := flakyService(t, 2, http.StatusBadGateway, nil)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(fastRetries)
+
+	if _, err := c.Get(context.Background(), "/system/status"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("service saw %d requests, want 3", n)
+	}
+}
+
+func TestRetriesStopAtTheConfiguredLimit(t *testing.T) {
+	srv, hits := flakyService(t, 10, http.StatusServiceUnavailable, nil)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(fastRetries)
+
+	_, err := c.Get(context.Background(), "/system/status")
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("err = %v, want the final 503", err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("service saw %d requests, want 1 + 2 retries", n)
+	}
+}
+
+func TestPostIsNotRetriedAfterTheServiceMayHaveActed(t *testing.T) {
+	srv, hits := flakyService(t, 1, http.StatusBadGateway, nil)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(fastRetries)
+
+	if _, err := c.Post(context.Background(), "/command", map[string]string{"name": "RssSync"}); err == nil {
+		t.Fatal("Post succeeded, want the 502")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("command was sent %d times, want once", n)
+	}
+}
+
+func TestPostIsRetriedWhenRateLimited(t *testing.T) {
+	srv, hits := flakyService(t, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "0"})
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(fastRetries)
+
+	if _, err := c.Post(context.Background(), "/command", map[string]string{"name": "RssSync"}); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("service saw %d requests, want 2", n)
+	}
+}
+
+func TestPutRetriesResendTheBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(sent))
+		first := len(bodies) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(fastRetries)
+
+	if _, err := c.Put(context.Background(), "/series/1", map[string]int{"id": 1}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] == "" {
+		t.Errorf("bodies = %q, want the same body twice", bodies)
+	}
+}
+
+func TestRetryAfterLongerThanTheMaximumGivesUp(t *testing.T) {
+	srv, hits := flakyService(t, 1, http.StatusServiceUnavailable, map[string]string{"Retry-After": "120"})
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(fastRetries)
+
+	if _, err := c.Get(context.Background(), "/system/status"); err == nil {
+		t.Fatal("Get succeeded, want the 503 returned rather than waiting two minutes")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("service saw %d requests, want 1", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("seconds: got %v, %v", d, ok)
+	}
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(at); !ok || d <= 58*time.Second || d > time.Minute {
+		t.Errorf("date: got %v, %v", d, ok)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Error("garbage parsed as a delay")
+	}
+}
+
+func TestBreakerFailsFastAndProbesAfterCooldown(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(RetryPolicy{
+		BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := c.Get(ctx, "/system/status"); errors.Is(err, ErrUnavailable) {
+			t.Fatalf("breaker opened early: %v", err)
+		}
+	}
+	_, err := c.Get(ctx, "/system/status")
+	if !errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "sonarr instance unavailable") {
+		t.Fatalf("err = %v, want sonarr instance unavailable", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("service saw %d requests while the breaker was open, want 2", n)
+	}
+
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := c.Get(ctx, "/system/status"); err != nil {
+		t.Fatalf("probe after cooldown: %v", err)
+	}
+	if _, err := c.Get(ctx, "/system/status"); err != nil {
+		t.Errorf("breaker stayed open after a successful probe: %v", err)
+	}
+}
+
+func TestBreakerIgnoresErrorsTheServiceChoseToReturn(t *testing.T) {
+	srv, _ := flakyService(t, 10, http.StatusNotFound, nil)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(RetryPolicy{
+		BreakerThreshold: 1, BreakerCooldown: time.Minute,
+	})
+	for range 3 {
+		if _, err := c.Get(context.Background(), "/series/9"); errors.Is(err, ErrUnavailable) {
+			t.Fatalf("404s opened the breaker: %v", err)
+		}
+	}
+}
+
+func TestBreakerHalfOpenAdmitsOneProbe(t *testing.T) {
+	b := newBreaker(1, 0)
+	b.record(false)
+	if _, ok := b.allow(); !ok {
+		t.Fatal("no probe allowed after the cooldown")
+	}
+	if _, ok := b.allow(); ok {
+		t.Error("a second call was allowed while the probe was in flight")
+	}
+	b.release()
+	if _, ok := b.allow(); !ok {
+		t.Error("a released probe kept the breaker half-open")
+	}
+}
+
+// A refused connection never reached the service, so even a command is
+// safe to send again.
+func TestUnreachableInstanceIsRetriedEvenForPost(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	c := NewClient("http://"+addr, SonarrSpec, Credentials{APIKey: "k"}).WithPolicy(fastRetries)
+	_, err = c.Post(context.Background(), "/command", map[string]string{"name": "RssSync"})
+	if err == nil || !strings.Contains(err.Error(), "request failed") {
+		t.Fatalf("err = %v, want a connection failure", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := c.send(context.Background(), req, 0); !a.unsent || !a.retryable(http.MethodPost) {
+		t.Errorf("refused connection: unsent = %v, want a retryable unsent attempt", a.unsent)
+	}
+}
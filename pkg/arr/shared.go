@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // HealthIssue is a warning or error reported by a service's health checks.
@@ -26,15 +28,17 @@ type DiskSpace struct {
 
 // QueueItem is an in-progress or pending download.
 type QueueItem struct {
-	ID             int    `json:"id"`
-	Title          string `json:"title"`
-	Status         string `json:"status,omitempty"`
-	TimeLeft       string `json:"timeleft,omitempty"`
-	Size           int64  `json:"size,omitempty"`
-	SizeLeft       int64  `json:"sizeleft,omitempty"`
-	Protocol       string `json:"protocol,omitempty"`
-	DownloadClient string `json:"downloadClient,omitempty"`
-	ErrorMessage   string `json:"errorMessage,omitempty"`
+	ID                   int    `json:"id"`
+	Title                string `json:"title"`
+	Status               string `json:"status,omitempty"`
+	TimeLeft             string `json:"timeleft,omitempty"`
+	Size                 int64  `json:"size,omitempty"`
+	SizeLeft             int64  `json:"sizeleft,omitempty"`
+	Protocol             string `json:"protocol,omitempty"`
+	DownloadClient       string `json:"downloadClient,omitempty"`
+	ErrorMessage         string `json:"errorMessage,omitempty"`
+	TrackedDownloadState string `json:"trackedDownloadState,omitempty" jsonschema:"importPending or importBlocked when the download finished but was not imported"`
+	DownloadID           string `json:"downloadId,omitempty" jsonschema:"pass to the manual_import_candidates tool when the download is stuck"`
 }
 
 // HistoryRecord is a past grab, import, or failure.
@@ -45,11 +49,27 @@ type HistoryRecord struct {
 	SourceTitle string `json:"sourceTitle,omitempty"`
 }
 
-// CommandResult reports the outcome of triggering a background command.
+// CommandResult reports the state of a background command. Triggering one
+// returns it queued; the timing, message and exception fill in as it runs.
 type CommandResult struct {
-	ID     int    `json:"id"`
-	Name   string `json:"name"`
-	Status string `json:"status,omitempty"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status,omitempty" jsonschema:"queued, started, completed, failed, aborted, cancelled or orphaned"`
+	Message   string `json:"message,omitempty" jsonschema:"the service's latest progress or outcome message"`
+	Exception string `json:"exception,omitempty" jsonschema:"why the command failed"`
+	Started   string `json:"started,omitempty"`
+	Ended     string `json:"ended,omitempty"`
+	Duration  string `json:"duration,omitempty" jsonschema:"run time as hh:mm:ss.fffffff"`
+}
+
+// Finished reports whether the command has stopped running, successfully or
+// not. A queued or started command is still in flight.
+func (r CommandResult) Finished() bool {
+	switch r.Status {
+	case "completed", "failed", "aborted", "cancelled", "orphaned":
+		return true
+	}
+	return false
 }
 
 // Episode is the trimmed view of a Sonarr episode.
@@ -148,6 +168,39 @@ func RunCommand(ctx context.Context, c *Client, name string, extra map[string]an
 	return out, nil
 }
 
+// GetCommand returns the current state of a command started earlier.
+func GetCommand(ctx context.Context, c *Client, id int) (CommandResult, error) {
+	return GetJSON[CommandResult](ctx, c, "/command/"+itoa(id))
+}
+
+// WaitForCommand polls a command every interval until it finishes or ctx is
+// done, calling onPoll with each state it sees. It returns the last state
+// observed either way; when ctx ends first the error is ctx.Err(), so callers
+// can tell a deadline from a failed request.
+func WaitForCommand(ctx context.Context, c *Client, cmd CommandResult, interval time.Duration, onPoll func(CommandResult)) (CommandResult, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for !cmd.Finished() {
+		select {
+		case <-ctx.Done():
+			return cmd, ctx.Err()
+		case <-ticker.C:
+		}
+		next, err := GetCommand(ctx, c, cmd.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return cmd, ctx.Err()
+			}
+			return cmd, err
+		}
+		cmd = next
+		if onPoll != nil {
+			onPoll(cmd)
+		}
+	}
+	return cmd, nil
+}
+
 // SonarrCalendar returns episodes airing between start and end (YYYY-MM-DD).
 func SonarrCalendar(ctx context.Context, c *Client, start, end string) ([]Episode, error) {
 	q := Query{}
@@ -206,7 +259,7 @@ type Tag struct {
 type TagDetail struct {
 	ID                  int    `json:"id"`
 	Label               string `json:"label"`
-	MediaCount          int    `json:"mediaCount" jsonschema:"series or movies carrying this tag"`
+	MediaCount          int    `json:"mediaCount" jsonschema:"series, movies, artists or authors carrying this tag"`
 	IndexerCount        int    `json:"indexerCount,omitempty"`
 	DownloadClientCount int    `json:"downloadClientCount,omitempty"`
 	ImportListCount     int    `json:"importListCount,omitempty"`
@@ -218,13 +271,16 @@ type TagDetail struct {
 
 // rawTagDetail mirrors the upstream resource. Sonarr and Radarr disagree on two
 // field names for the same concepts: Sonarr sends seriesIds and restrictionIds
-// where Radarr sends movieIds and releaseProfileIds. Decoding both keeps one
-// projection working for either service.
+// where Radarr sends movieIds and releaseProfileIds. Lidarr sends artistIds and
+// Readarr authorIds. Decoding all of them keeps one projection working for any
+// service.
 type rawTagDetail struct {
 	ID                int    `json:"id"`
 	Label             string `json:"label"`
 	SeriesIDs         []int  `json:"seriesIds"`
 	MovieIDs          []int  `json:"movieIds"`
+	ArtistIDs         []int  `json:"artistIds"`
+	AuthorIDs         []int  `json:"authorIds"`
 	IndexerIDs        []int  `json:"indexerIds"`
 	DownloadClientIDs []int  `json:"downloadClientIds"`
 	ImportListIDs     []int  `json:"importListIds"`
@@ -239,7 +295,7 @@ func (r rawTagDetail) toTagDetail() TagDetail {
 	return TagDetail{
 		ID:                  r.ID,
 		Label:               r.Label,
-		MediaCount:          len(r.SeriesIDs) + len(r.MovieIDs),
+		MediaCount:          len(r.SeriesIDs) + len(r.MovieIDs) + len(r.ArtistIDs) + len(r.AuthorIDs),
 		IndexerCount:        len(r.IndexerIDs),
 		DownloadClientCount: len(r.DownloadClientIDs),
 		ImportListCount:     len(r.ImportListIDs),
@@ -255,6 +311,11 @@ func ListTags(ctx context.Context, c *Client) ([]Tag, error) {
 	return GetJSON[[]Tag](ctx, c, "/tag")
 }
 
+// GetTag returns one tag by its id.
+func GetTag(ctx context.Context, c *Client, id int) (Tag, error) {
+	return GetJSON[Tag](ctx, c, "/tag/"+itoa(id))
+}
+
 // ListTagDetails returns each tag with a count of what carries it.
 func ListTagDetails(ctx context.Context, c *Client) ([]TagDetail, error) {
 	raw, err := GetJSON[[]rawTagDetail](ctx, c, "/tag/detail")
@@ -439,8 +500,8 @@ func (r rawProvider) toProvider() Provider {
 	}
 }
 
-// NamingConfig is the file and folder naming policy. The Sonarr-only and
-// Radarr-only fields are both present and omitted when empty, because the two
+// NamingConfig is the file and folder naming policy. The Sonarr-, Radarr-,
+// Lidarr- and Readarr-only fields are all present and omitted when empty, because the
 // services describe the same setting with different names.
 //
 // colonReplacementFormat is deliberately absent: Sonarr sends it as an integer
@@ -450,6 +511,8 @@ type NamingConfig struct {
 	ReplaceIllegalCharacters bool   `json:"replaceIllegalCharacters"`
 	RenameEpisodes           bool   `json:"renameEpisodes,omitempty" jsonschema:"Sonarr only"`
 	RenameMovies             bool   `json:"renameMovies,omitempty" jsonschema:"Radarr only"`
+	RenameTracks             bool   `json:"renameTracks,omitempty" jsonschema:"Lidarr only"`
+	RenameBooks              bool   `json:"renameBooks,omitempty" jsonschema:"Readarr only"`
 	StandardEpisodeFormat    string `json:"standardEpisodeFormat,omitempty"`
 	DailyEpisodeFormat       string `json:"dailyEpisodeFormat,omitempty"`
 	AnimeEpisodeFormat       string `json:"animeEpisodeFormat,omitempty"`
@@ -458,6 +521,11 @@ type NamingConfig struct {
 	SpecialsFolderFormat     string `json:"specialsFolderFormat,omitempty"`
 	StandardMovieFormat      string `json:"standardMovieFormat,omitempty"`
 	MovieFolderFormat        string `json:"movieFolderFormat,omitempty"`
+	StandardTrackFormat      string `json:"standardTrackFormat,omitempty"`
+	MultiDiscTrackFormat     string `json:"multiDiscTrackFormat,omitempty"`
+	ArtistFolderFormat       string `json:"artistFolderFormat,omitempty"`
+	StandardBookFormat       string `json:"standardBookFormat,omitempty"`
+	AuthorFolderFormat       string `json:"authorFolderFormat,omitempty"`
 }
 
 // QualityDefinition is the size policy for one quality level.
@@ -798,3 +866,386 @@ func ListUpdates(ctx context.Context, c *Client) ([]UpdatePackage, error) {
 func GetQueueStatus(ctx context.Context, c *Client) (QueueStatus, error) {
 	return GetJSON[QueueStatus](ctx, c, "/queue/status")
 }
+
+// --- interactive search ---
+
+// releaseSearchTimeout bounds an interactive search, which blocks while the
+// service queries every enabled indexer and evaluates each result. A slow
+// indexer alone can take most of a minute, well past the default read timeout.
+const releaseSearchTimeout = 2 * time.Minute
+
+// Release is one candidate from an interactive search, trimmed to what a
+// person weighs when picking by hand. The service returns candidates already
+// ranked by its own preference, rejected ones last.
+type Release struct {
+	GUID              string   `json:"guid" jsonschema:"pass to the grab_release tool together with indexerId"`
+	IndexerID         int      `json:"indexerId"`
+	Indexer           string   `json:"indexer,omitempty"`
+	Title             string   `json:"title"`
+	Quality           string   `json:"quality,omitempty"`
+	CustomFormatScore int      `json:"customFormatScore"`
+	Size              int64    `json:"size,omitempty" jsonschema:"bytes"`
+	Protocol          string   `json:"protocol,omitempty" jsonschema:"usenet or torrent"`
+	Seeders           *int     `json:"seeders,omitempty" jsonschema:"torrents only"`
+	Leechers          *int     `json:"leechers,omitempty" jsonschema:"torrents only"`
+	AgeDays           int      `json:"ageDays"`
+	Approved          bool     `json:"approved" jsonschema:"true when the service would grab this release itself"`
+	Rejections        []string `json:"rejections,omitempty" jsonschema:"why the service would not grab this release automatically"`
+}
+
+// rawRelease mirrors the upstream release resource. It also carries parsed
+// episode and language info, indexer flags and download and info URLs, none
+// of which bear on the choice.
+type rawRelease struct {
+	GUID              string        `json:"guid"`
+	IndexerID         int           `json:"indexerId"`
+	Indexer           string        `json:"indexer"`
+	Title             string        `json:"title"`
+	CustomFormatScore int           `json:"customFormatScore"`
+	Size              int64         `json:"size"`
+	Protocol          string        `json:"protocol"`
+	Seeders           *int          `json:"seeders"`
+	Leechers          *int          `json:"leechers"`
+	Age               int           `json:"age"`
+	Approved          bool          `json:"approved"`
+	Rejections        rejectionList `json:"rejections"`
+	Quality           struct {
+		Quality struct {
+			Name string `json:"name"`
+		} `json:"quality"`
+	} `json:"quality"`
+}
+
+func (r rawRelease) toRelease() Release {
+	return Release{
+		GUID: r.GUID, IndexerID: r.IndexerID, Indexer: r.Indexer, Title: r.Title,
+		Quality: r.Quality.Quality.Name, CustomFormatScore: r.CustomFormatScore,
+		Size: r.Size, Protocol: r.Protocol, Seeders: r.Seeders, Leechers: r.Leechers,
+		AgeDays: r.Age, Approved: r.Approved, Rejections: r.Rejections,
+	}
+}
+
+// rejectionList decodes release rejections. Current releases send plain
+// strings; newer development builds send objects with a reason and a type.
+// Only the reason is kept either way.
+type rejectionList []string
+
+// UnmarshalJSON accepts an array of strings or of {"reason": ...} objects.
+func (l *rejectionList) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("release rejections are not an array: %s", data)
+	}
+	*l = make(rejectionList, 0, len(items))
+	for _, item := range items {
+		var text string
+		if err := json.Unmarshal(item, &text); err == nil {
+			*l = append(*l, text)
+			continue
+		}
+		var obj struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal(item, &obj); err != nil {
+			return fmt.Errorf("release rejection is neither a string nor an object: %s", item)
+		}
+		*l = append(*l, obj.Reason)
+	}
+	return nil
+}
+
+// listReleases runs an interactive search scoped by q and returns at most
+// limit candidates in the service's own order, plus how many it found. The
+// search runs even when the limit is small, so the total is always real.
+func listReleases(ctx context.Context, c *Client, q Query, limit int) ([]Release, int, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	raw, err := GetJSON[[]rawRelease](ctx, c.WithTimeout(releaseSearchTimeout), "/release", q)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(raw)
+	if len(raw) > limit {
+		raw = raw[:limit]
+	}
+	out := make([]Release, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toRelease())
+	}
+	return out, total, nil
+}
+
+// GrabRelease sends one release from an earlier interactive search to the
+// download client, overriding the service's own choice and any rejections.
+// The service resolves the guid from its search cache, so the release must
+// come from a recent search on the same instance.
+func GrabRelease(ctx context.Context, c *Client, guid string, indexerID int) (Release, error) {
+	if guid == "" || indexerID <= 0 {
+		return Release{}, fmt.Errorf("guid and indexerId are both required; take them from a list_releases result")
+	}
+	body, err := c.Post(ctx, "/release", struct {
+		GUID      string `json:"guid"`
+		IndexerID int    `json:"indexerId"`
+	}{GUID: guid, IndexerID: indexerID})
+	if err != nil {
+		return Release{}, err
+	}
+	var raw rawRelease
+	if err := unmarshal(body, &raw); err != nil {
+		return Release{}, err
+	}
+	return raw.toRelease(), nil
+}
+
+// --- manual import ---
+
+// ImportCandidate is one file the service found for a manual import, with the
+// mapping it proposes and why it would not import the file on its own. Which
+// mapping fields are set depends on the service.
+type ImportCandidate struct {
+	Path           string   `json:"path"`
+	Size           int64    `json:"size,omitempty" jsonschema:"bytes"`
+	Quality        string   `json:"quality,omitempty"`
+	ReleaseGroup   string   `json:"releaseGroup,omitempty"`
+	DownloadID     string   `json:"downloadId,omitempty"`
+	MediaTitle     string   `json:"mediaTitle,omitempty" jsonschema:"title of the proposed series, movie, artist or author"`
+	SeriesID       int      `json:"seriesId,omitempty" jsonschema:"Sonarr only"`
+	SeasonNumber   *int     `json:"seasonNumber,omitempty" jsonschema:"Sonarr only"`
+	EpisodeIDs     []int    `json:"episodeIds,omitempty" jsonschema:"Sonarr only"`
+	MovieID        int      `json:"movieId,omitempty" jsonschema:"Radarr only"`
+	ArtistID       int      `json:"artistId,omitempty" jsonschema:"Lidarr only"`
+	AlbumID        int      `json:"albumId,omitempty" jsonschema:"Lidarr only"`
+	AlbumReleaseID int      `json:"albumReleaseId,omitempty" jsonschema:"Lidarr only"`
+	TrackIDs       []int    `json:"trackIds,omitempty" jsonschema:"Lidarr only"`
+	AuthorID       int      `json:"authorId,omitempty" jsonschema:"Readarr only"`
+	BookID         int      `json:"bookId,omitempty" jsonschema:"Readarr only"`
+	Rejections     []string `json:"rejections,omitempty" jsonschema:"why the service will not import this file automatically"`
+}
+
+// rawImportCandidate mirrors the upstream manual import resource for every
+// service. Each embeds its proposed media as whole resources; only their ids
+// and a title are kept. Quality and languages are kept verbatim because the
+// import command needs them back unchanged.
+type rawImportCandidate struct {
+	Path           string          `json:"path"`
+	Size           int64           `json:"size"`
+	ReleaseGroup   string          `json:"releaseGroup"`
+	DownloadID     string          `json:"downloadId"`
+	SeasonNumber   *int            `json:"seasonNumber"`
+	AlbumReleaseID int             `json:"albumReleaseId"`
+	IndexerFlags   int             `json:"indexerFlags"`
+	ReleaseType    string          `json:"releaseType"`
+	Quality        json.RawMessage `json:"quality"`
+	Languages      json.RawMessage `json:"languages"`
+	Rejections     rejectionList   `json:"rejections"`
+	Series         *idTitle        `json:"series"`
+	Movie          *idTitle        `json:"movie"`
+	Artist         *idTitle        `json:"artist"`
+	Album          *idTitle        `json:"album"`
+	Author         *idTitle        `json:"author"`
+	Book           *idTitle        `json:"book"`
+	Episodes       []idTitle       `json:"episodes"`
+	Tracks         []idTitle       `json:"tracks"`
+}
+
+// idTitle decodes the identity of an embedded resource under whichever name
+// the service gives its title.
+type idTitle struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	ArtistName string `json:"artistName"`
+	AuthorName string `json:"authorName"`
+}
+
+func (t *idTitle) name() string {
+	switch {
+	case t == nil:
+		return ""
+	case t.Title != "":
+		return t.Title
+	case t.ArtistName != "":
+		return t.ArtistName
+	}
+	return t.AuthorName
+}
+
+func (t *idTitle) id() int {
+	if t == nil {
+		return 0
+	}
+	return t.ID
+}
+
+func ids(items []idTitle) []int {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]int, 0, len(items))
+	for _, it := range items {
+		out = append(out, it.ID)
+	}
+	return out
+}
+
+func (r rawImportCandidate) toImportCandidate() ImportCandidate {
+	var quality struct {
+		Quality struct {
+			Name string `json:"name"`
+		} `json:"quality"`
+	}
+	_ = json.Unmarshal(r.Quality, &quality)
+
+	title := r.Series.name()
+	for _, t := range []*idTitle{r.Movie, r.Artist, r.Author} {
+		if title == "" {
+			title = t.name()
+		}
+	}
+	return ImportCandidate{
+		Path: r.Path, Size: r.Size, Quality: quality.Quality.Name,
+		ReleaseGroup: r.ReleaseGroup, DownloadID: r.DownloadID, MediaTitle: title,
+		SeriesID: r.Series.id(), SeasonNumber: r.SeasonNumber, EpisodeIDs: ids(r.Episodes),
+		MovieID:  r.Movie.id(),
+		ArtistID: r.Artist.id(), AlbumID: r.Album.id(), AlbumReleaseID: r.AlbumReleaseID, TrackIDs: ids(r.Tracks),
+		AuthorID: r.Author.id(), BookID: r.Book.id(),
+		Rejections: r.Rejections,
+	}
+}
+
+// manualImportQuery scopes a manual import scan to one download or one folder.
+func manualImportQuery(downloadID, folder string) (Query, error) {
+	switch {
+	case downloadID != "":
+		return Query{"downloadId": downloadID, "filterExistingFiles": "true"}, nil
+	case folder != "":
+		return Query{"folder": folder, "filterExistingFiles": "true"}, nil
+	}
+	return nil, fmt.Errorf("give a downloadId from the queue tool or a folder path to scan")
+}
+
+func listRawImportCandidates(ctx context.Context, c *Client, downloadID, folder string) ([]rawImportCandidate, error) {
+	q, err := manualImportQuery(downloadID, folder)
+	if err != nil {
+		return nil, err
+	}
+	return GetJSON[[]rawImportCandidate](ctx, c, "/manualimport", q)
+}
+
+// ListImportCandidates scans a download or folder the way the web UI's Manual
+// Import dialog does and returns each file with the proposed mapping.
+func ListImportCandidates(ctx context.Context, c *Client, downloadID, folder string) ([]ImportCandidate, error) {
+	raw, err := listRawImportCandidates(ctx, c, downloadID, folder)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ImportCandidate, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, r.toImportCandidate())
+	}
+	return out, nil
+}
+
+// ImportFile maps one candidate file to the media it should be imported as.
+// Only the fields for the target service are read: seriesId and episodeIds for
+// Sonarr, movieId for Radarr, artistId, albumId and trackIds for Lidarr, and
+// authorId and bookId for Readarr.
+type ImportFile struct {
+	Path           string `json:"path" jsonschema:"path of a file from manual_import_candidates"`
+	SeriesID       int    `json:"seriesId,omitempty"`
+	EpisodeIDs     []int  `json:"episodeIds,omitempty"`
+	MovieID        int    `json:"movieId,omitempty"`
+	ArtistID       int    `json:"artistId,omitempty"`
+	AlbumID        int    `json:"albumId,omitempty"`
+	AlbumReleaseID int    `json:"albumReleaseId,omitempty" jsonschema:"defaults to the release the candidate proposed"`
+	TrackIDs       []int  `json:"trackIds,omitempty"`
+	AuthorID       int    `json:"authorId,omitempty"`
+	BookID         int    `json:"bookId,omitempty"`
+}
+
+// missingFor reports what the mapping lacks for service, or "" when it is
+// complete enough for the import command to act on.
+func (f ImportFile) missingFor(service string) string {
+	switch service {
+	case "sonarr":
+		if f.SeriesID <= 0 || len(f.EpisodeIDs) == 0 {
+			return "seriesId and episodeIds"
+		}
+	case "radarr":
+		if f.MovieID <= 0 {
+			return "movieId"
+		}
+	case "lidarr":
+		if f.ArtistID <= 0 || f.AlbumID <= 0 || len(f.TrackIDs) == 0 {
+			return "artistId, albumId and trackIds"
+		}
+	case "readarr":
+		if f.AuthorID <= 0 || f.BookID <= 0 {
+			return "authorId and bookId"
+		}
+	}
+	return ""
+}
+
+// importCommandFile is one entry of the ManualImport command. The mapping comes
+// from the caller; quality, languages and release details are echoed from the
+// scan, since the command cannot import a file without them.
+type importCommandFile struct {
+	ImportFile
+	Quality      json.RawMessage `json:"quality,omitempty"`
+	Languages    json.RawMessage `json:"languages,omitempty"`
+	ReleaseGroup string          `json:"releaseGroup,omitempty"`
+	DownloadID   string          `json:"downloadId,omitempty"`
+	IndexerFlags int             `json:"indexerFlags,omitempty"`
+	ReleaseType  string          `json:"releaseType,omitempty"`
+}
+
+// ImportModes lists the ways ManualImport can bring files in: auto lets the
+// service pick per download client; move and copy force one.
+var ImportModes = []string{"auto", "move", "copy"}
+
+// ManualImport imports files from a download or folder with explicit mappings,
+// overriding whatever the service proposed or rejected. The scope is rescanned
+// first, so only files the service can see there are accepted, and an unknown
+// path fails the whole call before anything is imported. importMode is one of
+// ImportModes, auto when empty.
+func ManualImport(ctx context.Context, c *Client, downloadID, folder, importMode string, files []ImportFile) (CommandResult, error) {
+	if len(files) == 0 {
+		return CommandResult{}, fmt.Errorf("no files given; pass paths from manual_import_candidates with their mappings")
+	}
+	if importMode == "" {
+		importMode = "auto"
+	}
+	if !slices.Contains(ImportModes, importMode) {
+		return CommandResult{}, fmt.Errorf("unknown importMode %q; use one of %s", importMode, strings.Join(ImportModes, ", "))
+	}
+	for _, f := range files {
+		if missing := f.missingFor(c.spec.Name); missing != "" {
+			return CommandResult{}, fmt.Errorf("%s: %s needs %s", f.Path, c.spec.Name, missing)
+		}
+	}
+	raw, err := listRawImportCandidates(ctx, c, downloadID, folder)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	byPath := make(map[string]rawImportCandidate, len(raw))
+	for _, r := range raw {
+		byPath[r.Path] = r
+	}
+	out := make([]importCommandFile, 0, len(files))
+	for _, f := range files {
+		cand, ok := byPath[f.Path]
+		if !ok {
+			return CommandResult{}, fmt.Errorf("%s is not among the files found for this import; list them with manual_import_candidates", f.Path)
+		}
+		if f.AlbumReleaseID == 0 {
+			f.AlbumReleaseID = cand.AlbumReleaseID
+		}
+		out = append(out, importCommandFile{
+			ImportFile: f, Quality: cand.Quality, Languages: cand.Languages,
+			ReleaseGroup: cand.ReleaseGroup, DownloadID: cand.DownloadID,
+			IndexerFlags: cand.IndexerFlags, ReleaseType: cand.ReleaseType,
+		})
+	}
+	return RunCommand(ctx, c, "ManualImport", map[string]any{"files": out, "importMode": importMode})
+}
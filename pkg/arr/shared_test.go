@@ -2,7 +2,9 @@ package arr
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestListHealthIssuesTrimsToActionableFields(t *testing.T) {
@@ -222,3 +224,76 @@ func contains(haystack, needle string) bool {
 		return false
 	})()
 }
+
+func TestGetCommandReadsTheCommandByID(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"id":31,"name":"Backup","status":"completed",
+	  "duration":"00:00:04.2000000","ended":"2024-05-01T10:00:04Z","body":{"sendUpdatesToClient":true}}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	cmd, err := GetCommand(context.Background(), c, 31)
+	if err != nil {
+		t.Fatalf("GetCommand returned error: %v", err)
+	}
+	if got.path != "/api/v3/command/31" {
+		t.Errorf("path = %q, want /api/v3/command/31", got.path)
+	}
+	if !cmd.Finished() || cmd.Duration != "00:00:04.2000000" {
+		t.Errorf("command = %+v, want a finished command with its duration", cmd)
+	}
+}
+
+func TestWaitForCommandStopsAtTheDeadline(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"id":31,"name":"Backup","status":"started"}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	polls := 0
+	cmd, err := WaitForCommand(ctx, c, CommandResult{ID: 31, Status: "queued"}, 5*time.Millisecond,
+		func(CommandResult) { polls++ })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context deadline", err)
+	}
+	if cmd.Status != "started" || polls == 0 || len(got.paths) == 0 {
+		t.Errorf("command = %+v after %d polls, want the last state seen", cmd, polls)
+	}
+}
+
+func TestWaitForCommandReturnsAFinishedCommandWithoutPolling(t *testing.T) {
+	srv, got := fakeService(t, 200, `{}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	cmd, err := WaitForCommand(context.Background(), c, CommandResult{ID: 1, Status: "failed"}, time.Millisecond, nil)
+	if err != nil || cmd.Status != "failed" {
+		t.Fatalf("WaitForCommand = %+v, %v; want the failed command back", cmd, err)
+	}
+	if len(got.paths) != 0 {
+		t.Errorf("upstream polled %v for a command that had already finished", got.paths)
+	}
+}
+
+func TestGetSingleRecordFetchesByID(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"id":7,"title":"Severance","tvdbId":371980,"images":[]}`)
+	c := NewClient(srv.URL, SonarrSpec, Credentials{APIKey: "k"})
+
+	series, err := SonarrGetSeries(context.Background(), c, 7)
+	if err != nil {
+		t.Fatalf("SonarrGetSeries returned error: %v", err)
+	}
+	if got.path != "/api/v3/series/7" {
+		t.Errorf("path = %q, want /api/v3/series/7", got.path)
+	}
+	if series.Title != "Severance" || series.TVDBID != 371980 {
+		t.Errorf("series = %+v, want trimmed record", series)
+	}
+
+	srv, got = fakeService(t, 200, `{"id":3,"title":"Heat","hasFile":true}`)
+	c = NewClient(srv.URL, RadarrSpec, Credentials{APIKey: "k"})
+	movie, err := RadarrGetMovie(context.Background(), c, 3)
+	if err != nil {
+		t.Fatalf("RadarrGetMovie returned error: %v", err)
+	}
+	if got.path != "/api/v3/movie/3" || !movie.HasFile {
+		t.Errorf("path = %q, movie = %+v", got.path, movie)
+	}
+}
@@ -48,6 +48,15 @@ func SonarrListSeries(ctx context.Context, c *Client) ([]Series, error) {
 	return trimSeries(raw), nil
 }
 
+// SonarrGetSeries returns one series from the library by its internal id.
+func SonarrGetSeries(ctx context.Context, c *Client, id int) (Series, error) {
+	raw, err := GetJSON[rawSeries](ctx, c, "/series/"+itoa(id))
+	if err != nil {
+		return Series{}, err
+	}
+	return raw.toSeries(), nil
+}
+
 // SonarrLookupSeries searches configured indexers for series matching term.
 func SonarrLookupSeries(ctx context.Context, c *Client, term string) ([]Series, error) {
 	raw, err := GetJSON[[]rawSeries](ctx, c, "/series/lookup", Query{"term": term})
@@ -117,11 +126,15 @@ type SeriesEditRequest struct {
 // SonarrEditSeries applies a change to a set of series at once. This is how
 // monitoring, quality profiles, tags and the root folder are changed: the
 // endpoint takes a partial resource, so nothing the caller omits is touched.
+// Under DryRun it also reports which series would change, and how.
 func SonarrEditSeries(ctx context.Context, c *Client, req SeriesEditRequest) ([]Series, error) {
 	if len(req.Tags) > 0 && req.ApplyTags == "" {
 		// "add" is the only default that cannot remove a tag by surprise.
 		req.ApplyTags = "add"
 	}
+	if err := planEdits(ctx, c, "/series", "seriesIds", "title", req); err != nil {
+		return nil, err
+	}
 	body, err := c.Put(ctx, "/series/editor", req)
 	if err != nil {
 		return nil, err
@@ -264,3 +277,17 @@ func SonarrTriggerSearch(ctx context.Context, c *Client, seriesID int, seasonNum
 func SonarrRefreshSeries(ctx context.Context, c *Client, seriesID int) (CommandResult, error) {
 	return RunCommand(ctx, c, "RefreshSeries", map[string]any{"seriesId": seriesID})
 }
+
+// SonarrListReleases runs an interactive search for one episode, or for a whole
+// season when episodeID is zero. Season searches return season packs as well as
+// single episodes.
+func SonarrListReleases(ctx context.Context, c *Client, seriesID int, seasonNumber *int, episodeID int, limit int) ([]Release, int, error) {
+	switch {
+	case episodeID > 0:
+		return listReleases(ctx, c, Query{"episodeId": itoa(episodeID)}, limit)
+	case seriesID > 0 && seasonNumber != nil:
+		return listReleases(ctx, c, Query{"seriesId": itoa(seriesID), "seasonNumber": itoa(*seasonNumber)}, limit)
+	default:
+		return nil, 0, fmt.Errorf("give an episodeId, or a seriesId with a seasonNumber; interactive search has no whole-series scope")
+	}
+}
@@ -98,3 +98,16 @@ func TestDeleteTagTargetsTheTagID(t *testing.T) {
 		t.Errorf("request = %s %s, want DELETE /api/v3/tag/7", got.method, got.path)
 	}
 }
+
+func TestGetTagFetchesOneTag(t *testing.T) {
+	srv, got := fakeService(t, 200, `{"label":"kids","id":3}`)
+	c := NewClient(srv.URL, RadarrSpec, Credentials{APIKey: "k"})
+
+	tag, err := GetTag(context.Background(), c, 3)
+	if err != nil {
+		t.Fatalf("GetTag returned error: %v", err)
+	}
+	if got.path != "/api/v3/tag/3" || tag.Label != "kids" {
+		t.Errorf("GET %s gave %+v, want kids from /api/v3/tag/3", got.path, tag)
+	}
+}
@@ -0,0 +1,186 @@
+// Package audit keeps an append-only JSONL record of mutating tool calls.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Tool     string    `json:"tool"`
+	Service  string    `json:"service"`
+	Instance string    `json:"instance"`
+	Access   string    `json:"access"`
+	// Arguments are the tool input with secret-looking values redacted.
+	Arguments map[string]any `json:"arguments,omitempty"`
+	// Decision is how the permission gate ruled: allowed, approved,
+	// declined, fallback or denied.
+	Decision string `json:"decision"`
+	Session  string `json:"session,omitempty"`
+	Client   string `json:"client,omitempty"`
+	// Caller is the authenticated identity on the HTTP transport.
+	Caller         string `json:"caller,omitempty"`
+	UpstreamStatus int    `json:"upstreamStatus,omitempty"`
+	// DryRun marks a call that was authorized but only reported what it
+	// would have sent.
+	DryRun     bool   `json:"dryRun,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// Log appends entries to a file, rotating it once it grows past a size limit.
+// A nil *Log discards everything, so callers need not check whether auditing
+// is enabled.
+type Log struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open starts appending to the configured file. It returns nil, and no error,
+// when auditing is not configured.
+func Open(cfg config.AuditConfig) (*Log, error) {
+	if cfg.Path == "" {
+		return nil, nil
+	}
+	l := &Log{
+		path:       cfg.Path,
+		maxBytes:   int64(cfg.MaxSizeMB) << 20,
+		maxBackups: cfg.MaxBackups,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) open() error {
+	// The path is chosen by the operator in the config file.
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	l.file, l.size = f, info.Size()
+	return nil
+}
+
+// Record appends one entry, rotating first if it would overflow the file.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxBytes > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts path.1 .. path.N-1 up by one, dropping the oldest, moves the
+// live file to path.1 and starts a fresh one.
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("rotating audit log: %w", err)
+	}
+	if l.maxBackups > 0 {
+		for i := l.maxBackups - 1; i >= 1; i-- {
+			_ = os.Rename(l.backup(i), l.backup(i+1))
+		}
+		if err := os.Rename(l.path, l.backup(1)); err != nil {
+			return fmt.Errorf("rotating audit log: %w", err)
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return fmt.Errorf("rotating audit log: %w", err)
+	}
+	return l.open()
+}
+
+func (l *Log) backup(n int) string { return fmt.Sprintf("%s.%d", l.path, n) }
+
+// Close flushes and closes the file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// sensitive lists substrings of argument names whose values are never logged.
+var sensitive = []string{"apikey", "password", "passphrase", "secret", "token", "credential"}
+
+// Redact converts a tool input to a generic map, replacing the value of any
+// field whose name looks like it holds a secret.
+func Redact(in any) map[string]any {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	redactMap(out)
+	return out
+}
+
+func redactMap(m map[string]any) {
+	for k, v := range m {
+		if isSensitive(k) {
+			m[k] = "[redacted]"
+			continue
+		}
+		switch inner := v.(type) {
+		case map[string]any:
+			redactMap(inner)
+		case []any:
+			for _, item := range inner {
+				if im, ok := item.(map[string]any); ok {
+					redactMap(im)
+				}
+			}
+		}
+	}
+}
+
+func isSensitive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, s := range sensitive {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
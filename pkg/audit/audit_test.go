@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not JSON: %v", sc.Text(), err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestOpenWithoutPathDisablesAuditing(t *testing.T) {
+	l, err := Open(config.AuditConfig{})
+	if err != nil || l != nil {
+		t.Fatalf("Open = %v, %v; want nil, nil", l, err)
+	}
+	if err := l.Record(Entry{Tool: "x"}); err != nil {
+		t.Errorf("Record on a nil log returned %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close on a nil log returned %v", err)
+	}
+}
+
+func TestRecordAppendsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for _, tool := range []string{"sonarr_create_tag", "sonarr_delete_tag"} {
+		l, err := Open(config.AuditConfig{Path: path, MaxSizeMB: 1})
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		if err := l.Record(Entry{Tool: tool, Decision: "allowed"}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		_ = l.Close()
+	}
+
+	got := readEntries(t, path)
+	if len(got) != 2 || got[0].Tool != "sonarr_create_tag" || got[1].Tool != "sonarr_delete_tag" {
+		t.Errorf("entries = %+v, want both calls in order", got)
+	}
+}
+
+func TestRecordRotatesBySizeAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(config.AuditConfig{Path: path, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+	// A limit of a few hundred bytes fits one entry per file.
+	l.maxBytes = 200
+
+	for _, tool := range []string{"one", "two", "three", "four"} {
+		if err := l.Record(Entry{Tool: tool, Arguments: map[string]any{"pad": strings.Repeat("x", 100)}}); err != nil {
+			t.Fatalf("Record %s: %v", tool, err)
+		}
+	}
+
+	for file, want := range map[string]string{path: "four", path + ".1": "three", path + ".2": "two"} {
+		got := readEntries(t, file)
+		if len(got) != 1 || got[0].Tool != want {
+			t.Errorf("%s = %+v, want only %q", filepath.Base(file), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups, found %s.3", filepath.Base(path))
+	}
+}
+
+func TestRedactHidesSecretLookingArguments(t *testing.T) {
+	in := struct {
+		Instance string         `json:"instance"`
+		APIKey   string         `json:"apiKey"`
+		Fields   map[string]any `json:"fields"`
+	}{
+		Instance: "main",
+		APIKey:   "hunter2",
+		Fields:   map[string]any{"password": "hunter2", "host": "nzb.example"},
+	}
+
+	got := Redact(in)
+	raw, _ := json.Marshal(got)
+	if strings.Contains(string(raw), "hunter2") {
+		t.Errorf("redacted arguments %s still contain the secret", raw)
+	}
+	if got["instance"] != "main" || got["fields"].(map[string]any)["host"] != "nzb.example" {
+		t.Errorf("redacted arguments %s lost ordinary fields", raw)
+	}
+}
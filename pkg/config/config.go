@@ -14,6 +14,23 @@ type Instance struct {
 	Default bool   `yaml:"default"`
 	// Permissions optionally overrides the global policy for this instance.
 	Permissions *Permissions `yaml:"permissions"`
+	// TLS optionally customises certificate handling for an https URL.
+	TLS *TLSConfig `yaml:"tls"`
+	// Headers are sent with every request, for an auth proxy in front of the
+	// instance. Values may reference ${VAR}.
+	Headers map[string]string `yaml:"headers"`
+	// BasicAuth sends HTTP basic credentials alongside the API key, for a
+	// proxy that asks for them.
+	BasicAuth *BasicAuth `yaml:"basicAuth"`
+	// Commands optionally limits the commands run_command may start on this
+	// instance, replacing the service-wide list.
+	Commands []string `yaml:"commands"`
+}
+
+// BasicAuth holds HTTP basic credentials. Both fields may reference ${VAR}.
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
 }
 
 // Config is the fully resolved server configuration.
@@ -21,6 +38,8 @@ type Config struct {
 	Server      ServerConfig          `yaml:"server"`
 	Permissions Permissions           `yaml:"permissions"`
 	Services    map[string][]Instance `yaml:"services"`
+	// Commands limits, per service, the commands run_command may start.
+	Commands map[string][]string `yaml:"commands"`
 }
 
 // InstanceNames returns configured instance names for service, in config order.
@@ -44,6 +63,19 @@ func (c *Config) ConfiguredServices() []string {
 	return out
 }
 
+// AllowedCommands returns the commands run_command may start on inst of
+// service: the instance's own list, else the service's under commands, else
+// known, every command the service accepts. An empty list allows none.
+func (c *Config) AllowedCommands(service string, inst *Instance, known []string) []string {
+	if inst != nil && inst.Commands != nil {
+		return inst.Commands
+	}
+	if list, ok := c.Commands[service]; ok {
+		return list
+	}
+	return known
+}
+
 // EffectiveMode returns the permission mode governing inst, preferring the
 // instance override over the global policy.
 func (c *Config) EffectiveMode(inst *Instance) Mode {
@@ -61,6 +93,26 @@ func (c *Config) EffectivePermissions(inst *Instance) Permissions {
 	return c.Permissions
 }
 
+// CallerPermissions returns the policy governing a call to inst made with tok,
+// preferring the token override over the instance and global policy. A nil
+// tok, as on stdio, falls back to EffectivePermissions.
+func (c *Config) CallerPermissions(inst *Instance, tok *Token) Permissions {
+	if tok != nil && tok.Permissions != nil {
+		return *tok.Permissions
+	}
+	return c.EffectivePermissions(inst)
+}
+
+// Token returns the configured bearer token named name, or nil.
+func (a AuthConfig) Token(name string) *Token {
+	for i := range a.Tokens {
+		if a.Tokens[i].Name == name {
+			return &a.Tokens[i]
+		}
+	}
+	return nil
+}
+
 // Resolve returns the instance of service selected by name. An empty name
 // selects the instance marked default, or the sole instance when only one is
 // configured. Errors name the valid instances so the caller can correct itself.
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -125,3 +126,25 @@ func TestInstanceNamesPreservesConfigOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestAllowedCommandsPreferInstanceThenServiceThenKnown(t *testing.T) {
+	known := []string{"RssSync", "Backup", "RescanFolders"}
+	c := &Config{Commands: map[string][]string{"lidarr": {"RssSync"}}}
+	own := &Instance{Name: "own", Commands: []string{"Backup"}}
+	none := &Instance{Name: "none", Commands: []string{}}
+
+	for _, tc := range []struct {
+		service string
+		inst    *Instance
+		want    []string
+	}{
+		{"lidarr", own, []string{"Backup"}},
+		{"lidarr", &Instance{Name: "main"}, []string{"RssSync"}},
+		{"readarr", &Instance{Name: "main"}, known},
+		{"lidarr", none, []string{}},
+	} {
+		if got := c.AllowedCommands(tc.service, tc.inst, known); !slices.Equal(got, tc.want) {
+			t.Errorf("%s/%s: allowed = %v, want %v", tc.service, tc.inst.Name, got, tc.want)
+		}
+	}
+}
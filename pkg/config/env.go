@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// envSettings are the per-instance variables loadFromEnv understands, as the
+// last part of <SERVICE>__<NAME>__<SETTING>.
+var envSettings = []string{
+	"URL", "API_KEY", "API_KEY_FILE", "DEFAULT",
+	"PERMISSIONS_MODE", "PERMISSIONS_CONFIRM_SCOPE", "PERMISSIONS_FALLBACK",
+	"COMMANDS",
+}
+
+// loadFromEnv builds instances from environment variables, covering
+// docker-compose deployments with no config file. <SERVICE>_URL and
+// <SERVICE>_API_KEY give the single-instance quickstart one instance named
+// default; <SERVICE>_API_KEY_FILE may name a mounted secret in place of the
+// key. <SERVICE>__<NAME>__<SETTING> variables give any number of named
+// instances, as a services block in the config file would.
+func (c *Config) loadFromEnv() error {
+	named, err := namedInstancesFromEnv(os.Environ())
+	if err != nil {
+		return err
+	}
+	for _, svc := range KnownServices {
+		prefix := strings.ToUpper(svc)
+		url := os.Getenv(prefix + "_URL")
+		key := os.Getenv(prefix + "_API_KEY")
+		if path := os.Getenv(prefix + "_API_KEY_FILE"); path != "" {
+			if key != "" {
+				return fmt.Errorf("set %s_API_KEY or %s_API_KEY_FILE, not both", prefix, prefix)
+			}
+			var err error
+			if key, err = readSecretFile(path); err != nil {
+				return fmt.Errorf("%s_API_KEY_FILE: %w", prefix, err)
+			}
+		}
+		if instances := named[svc]; len(instances) > 0 {
+			if url != "" || key != "" {
+				return fmt.Errorf("set %s_URL and %s_API_KEY, or %s__<NAME>__* variables, not both",
+					prefix, prefix, prefix)
+			}
+			c.Services[svc] = instances
+			continue
+		}
+		if url == "" || key == "" {
+			continue
+		}
+		c.Services[svc] = []Instance{{Name: "default", URL: url, APIKey: key, Default: true}}
+	}
+	if len(c.Services) == 0 {
+		return fmt.Errorf("no configuration found: pass --config, or set <SERVICE>_URL and "+
+			"<SERVICE>_API_KEY (or <SERVICE>__<NAME>__URL and <SERVICE>__<NAME>__API_KEY) "+
+			"for at least one of: %s", strings.Join(KnownServices, ", "))
+	}
+	return nil
+}
+
+// namedInstancesFromEnv collects <SERVICE>__<NAME>__<SETTING> variables from
+// environ into instances, keyed by service. Instance names are lowercased, so
+// SONARR__4K__URL configures sonarr instance "4k"; instances are in name
+// order. An empty value counts as unset, as it does for ${VAR}.
+func namedInstancesFromEnv(environ []string) (map[string][]Instance, error) {
+	known := map[string]bool{}
+	for _, s := range KnownServices {
+		known[s] = true
+	}
+	settings := map[string]bool{}
+	for _, s := range envSettings {
+		settings[s] = true
+	}
+
+	// vars maps SERVICE__NAME to that instance's settings.
+	vars := map[string]map[string]string{}
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		parts := strings.Split(k, "__")
+		if len(parts) < 2 || !known[strings.ToLower(parts[0])] || v == "" {
+			continue
+		}
+		if len(parts) != 3 || parts[1] == "" {
+			return nil, fmt.Errorf("%s: want <SERVICE>__<NAME>__<SETTING>", k)
+		}
+		setting := strings.ToUpper(parts[2])
+		if !settings[setting] {
+			return nil, fmt.Errorf("%s: unknown setting %q; want one of: %s",
+				k, parts[2], strings.Join(envSettings, ", "))
+		}
+		id := strings.ToUpper(parts[0] + "__" + parts[1])
+		if vars[id] == nil {
+			vars[id] = map[string]string{}
+		}
+		vars[id][setting] = v
+	}
+
+	ids := make([]string, 0, len(vars))
+	for id := range vars {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	out := map[string][]Instance{}
+	for _, id := range ids {
+		svc, name, _ := strings.Cut(id, "__")
+		inst, err := instanceFromEnv(id, strings.ToLower(name), vars[id])
+		if err != nil {
+			return nil, err
+		}
+		out[strings.ToLower(svc)] = append(out[strings.ToLower(svc)], inst)
+	}
+	return out, nil
+}
+
+// instanceFromEnv builds one instance from its settings. prefix is the
+// SERVICE__NAME the variables share, for error messages.
+func instanceFromEnv(prefix, name string, set map[string]string) (Instance, error) {
+	inst := Instance{Name: name, URL: set["URL"], APIKey: set["API_KEY"]}
+	if path := set["API_KEY_FILE"]; path != "" {
+		if inst.APIKey != "" {
+			return inst, fmt.Errorf("set %s__API_KEY or %s__API_KEY_FILE, not both", prefix, prefix)
+		}
+		var err error
+		if inst.APIKey, err = readSecretFile(path); err != nil {
+			return inst, fmt.Errorf("%s__API_KEY_FILE: %w", prefix, err)
+		}
+	}
+	if inst.URL == "" {
+		return inst, fmt.Errorf("%s__URL is not set", prefix)
+	}
+	if inst.APIKey == "" {
+		return inst, fmt.Errorf("%s__API_KEY (or %s__API_KEY_FILE) is not set", prefix, prefix)
+	}
+	if v := set["DEFAULT"]; v != "" {
+		var err error
+		if inst.Default, err = strconv.ParseBool(v); err != nil {
+			return inst, fmt.Errorf("%s__DEFAULT: want true or false, got %q", prefix, v)
+		}
+	}
+
+	mode, scope, fallback := set["PERMISSIONS_MODE"], set["PERMISSIONS_CONFIRM_SCOPE"], set["PERMISSIONS_FALLBACK"]
+	if mode != "" || scope != "" || fallback != "" {
+		inst.Permissions = &Permissions{Mode: Mode(mode), ConfirmScope: Scope(scope), Fallback: Fallback(fallback)}
+	}
+	if v := set["COMMANDS"]; v != "" {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				inst.Commands = append(inst.Commands, name)
+			}
+		}
+	}
+	return inst, nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Named instances from the environment must come out exactly as the same
+// instances written in a config file.
+func TestNamedEnvInstancesMatchTheConfigFile(t *testing.T) {
+	t.Setenv("SONARR__MAIN__URL", "http://a:8989")
+	t.Setenv("SONARR__MAIN__API_KEY", "k1")
+	t.Setenv("SONARR__MAIN__DEFAULT", "true")
+	t.Setenv("SONARR__4K__URL", "http://b:8989")
+	t.Setenv("SONARR__4K__API_KEY_FILE", writeSecret(t, "k2\n"))
+	t.Setenv("SONARR__4K__PERMISSIONS_MODE", "readonly")
+	t.Setenv("SONARR__4K__COMMANDS", "RssSync, Backup,")
+	t.Setenv("RADARR__MAIN__URL", "http://c:7878")
+	t.Setenv("RADARR__MAIN__API_KEY", "k3")
+
+	fromEnv, err := Load("")
+	if err != nil {
+		t.Fatalf("Load from env: %v", err)
+	}
+	fromFile, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: 4k
+      url: http://b:8989
+      apiKey: k2
+      permissions:
+        mode: readonly
+      commands: [RssSync, Backup]
+    - name: main
+      url: http://a:8989
+      apiKey: k1
+      default: true
+  radarr:
+    - name: main
+      url: http://c:7878
+      apiKey: k3
+`))
+	if err != nil {
+		t.Fatalf("Load from file: %v", err)
+	}
+	if !reflect.DeepEqual(fromEnv.Services, fromFile.Services) {
+		t.Errorf("services from env = %+v\nwant, as from the file, %+v", fromEnv.Services, fromFile.Services)
+	}
+	if inst, _ := fromEnv.Resolve("sonarr", ""); inst.Name != "main" {
+		t.Errorf("default sonarr = %q, want main", inst.Name)
+	}
+}
+
+func TestNamedEnvInstancesAreValidated(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		environ []string
+		want    string
+	}{
+		{"typo", []string{"SONARR__4K__URL=http://b", "SONARR__4K__APIKEY=k"}, `unknown setting "APIKEY"`},
+		{"no key", []string{"SONARR__4K__URL=http://b"}, "SONARR__4K__API_KEY (or"},
+		{"no url", []string{"SONARR__4K__API_KEY=k"}, "SONARR__4K__URL is not set"},
+		{"bad default", []string{"SONARR__4K__URL=http://b", "SONARR__4K__API_KEY=k", "SONARR__4K__DEFAULT=yes please"}, "want true or false"},
+		{"too deep", []string{"SONARR__4K__TLS__CA_FILE=/ca.pem"}, "want <SERVICE>__<NAME>__<SETTING>"},
+	} {
+		_, err := namedInstancesFromEnv(tc.environ)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+
+	got, err := namedInstancesFromEnv([]string{"PATH=/bin", "MY__APP__URL=x", "SONARR__4K__URL="})
+	if err != nil || len(got) != 0 {
+		t.Errorf("unrelated and empty variables gave %v, %v; want nothing", got, err)
+	}
+}
+
+func TestNamedEnvInstancesCannotMixWithTheSingleInstanceVariables(t *testing.T) {
+	t.Setenv("SONARR_URL", "http://a:8989")
+	t.Setenv("SONARR_API_KEY", "k")
+	t.Setenv("SONARR__4K__URL", "http://b:8989")
+	t.Setenv("SONARR__4K__API_KEY", "k2")
+
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "not both") {
+		t.Errorf("err = %v, want the mix rejected", err)
+	}
+}
+
+func TestNamedEnvInstancePermissionsAreChecked(t *testing.T) {
+	t.Setenv("SONARR__4K__URL", "http://b:8989")
+	t.Setenv("SONARR__4K__API_KEY", "k")
+	t.Setenv("SONARR__4K__PERMISSIONS_MODE", "yolo")
+
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "sonarr.4k.permissions.mode") {
+		t.Errorf("err = %v, want the unknown mode rejected", err)
+	}
+}
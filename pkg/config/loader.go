@@ -2,10 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/textproto"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -48,38 +52,203 @@ const (
 
 // KnownServices lists the services this build can expose tools for. Config
 // referencing anything else is rejected rather than silently ignored.
-var KnownServices = []string{"sonarr", "radarr", "prowlarr", "bazarr"}
+var KnownServices = []string{"sonarr", "radarr", "lidarr", "readarr", "prowlarr", "bazarr"}
+
+// housekeepingCommands are the commands every media service accepts.
+var housekeepingCommands = []string{
+	"RssSync", "ImportListSync", "RefreshMonitoredDownloads", "CheckHealth", "Backup",
+	"Housekeeping", "CleanUpRecycleBin", "ClearBlocklist",
+}
+
+// KnownCommands lists, per service, every command a commands allowlist may
+// name, in the casing the service uses. It is a superset of what run_command
+// offers by default: RescanFolders, which rescans the whole Lidarr or Readarr
+// library, runs only where an allowlist names it.
+var KnownCommands = map[string][]string{
+	"sonarr": append([]string{
+		"RefreshSeries", "RescanSeries", "MissingEpisodeSearch", "CutoffUnmetEpisodeSearch",
+		"DownloadedEpisodesScan",
+	}, housekeepingCommands...),
+	"radarr": append([]string{
+		"RefreshMovie", "RescanMovie", "MissingMoviesSearch", "CutoffUnmetMoviesSearch",
+		"DownloadedMoviesScan", "RefreshCollections",
+	}, housekeepingCommands...),
+	"lidarr": append([]string{
+		"RefreshArtist", "RescanFolders", "MissingAlbumSearch", "CutoffUnmetAlbumSearch",
+		"DownloadedAlbumsScan",
+	}, housekeepingCommands...),
+	"readarr": append([]string{
+		"RefreshAuthor", "RescanFolders", "MissingBookSearch", "CutoffUnmetBookSearch",
+		"DownloadedBooksScan",
+	}, housekeepingCommands...),
+	"prowlarr": {"ApplicationIndexerSync", "Backup", "CheckHealth", "Housekeeping"},
+}
 
 // Permissions describes how mutating tools are treated.
 type Permissions struct {
 	Mode         Mode     `yaml:"mode"`
 	ConfirmScope Scope    `yaml:"confirmScope"`
 	Fallback     Fallback `yaml:"fallback"`
+	// AllowTools, when set, limits the tools advertised and callable to those
+	// matching one of its patterns. See ForTool for the pattern syntax.
+	AllowTools []string `yaml:"allowTools"`
+	// DenyTools hides and refuses tools matching any of its patterns, even
+	// ones AllowTools lets through.
+	DenyTools []string `yaml:"denyTools"`
+	// Tools overrides Mode for the tools matching each pattern.
+	Tools map[string]Mode `yaml:"tools"`
 }
 
 // ServerConfig holds transport and logging settings.
 type ServerConfig struct {
-	Transport string `yaml:"transport"`
-	Addr      string `yaml:"addr"`
-	LogLevel  string `yaml:"logLevel"`
+	Transport string          `yaml:"transport"`
+	Addr      string          `yaml:"addr"`
+	LogLevel  string          `yaml:"logLevel"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Audit     AuditConfig     `yaml:"audit"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Readiness ReadinessConfig `yaml:"readiness"`
+	Upstream  UpstreamConfig  `yaml:"upstream"`
+	// DryRun makes every write and destructive tool report the request it
+	// would send instead of sending it.
+	DryRun bool `yaml:"dryRun"`
+}
+
+// UpstreamConfig decides how requests to the services are retried and when an
+// instance that keeps failing is cut off for a while.
+type UpstreamConfig struct {
+	// Retries is how many times a request that failed transiently is repeated.
+	// Only reads and PUTs are repeated after the service may have seen them.
+	Retries int `yaml:"retries"`
+	// RetryBaseDelay is the backoff before the first retry. It doubles for
+	// each retry after that, up to RetryMaxDelay, with full jitter applied.
+	RetryBaseDelay time.Duration `yaml:"retryBaseDelay"`
+	// RetryMaxDelay caps the backoff, and any Retry-After the service asks
+	// for: a longer wait gives up instead.
+	RetryMaxDelay time.Duration `yaml:"retryMaxDelay"`
+	// BreakerThreshold is how many calls in a row must fail before the
+	// instance is reported unavailable without being contacted; 0 disables it.
+	BreakerThreshold int `yaml:"breakerThreshold"`
+	// BreakerCooldown is how long calls fail fast before one is let through
+	// to test whether the instance has recovered.
+	BreakerCooldown time.Duration `yaml:"breakerCooldown"`
+}
+
+// DefaultUpstream is the retry and circuit breaker policy used unless the
+// configuration overrides it.
+var DefaultUpstream = UpstreamConfig{
+	Retries:          2,
+	RetryBaseDelay:   250 * time.Millisecond,
+	RetryMaxDelay:    5 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// ReadyPolicy decides how failing instances affect /ready.
+type ReadyPolicy string
+
+// Supported readiness policies.
+const (
+	// ReadyAll is unready as soon as any configured instance is unreachable.
+	ReadyAll ReadyPolicy = "all"
+	// ReadyAny stays ready while at least one instance answers.
+	ReadyAny ReadyPolicy = "any"
+)
+
+// ReadinessConfig tunes the HTTP transport's /ready endpoint.
+type ReadinessConfig struct {
+	Policy ReadyPolicy `yaml:"policy"`
+	// CacheTTL is how long a round of instance checks is reused, so frequent
+	// probes do not hammer the services.
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+}
+
+// TracingConfig enables OpenTelemetry trace export. Tracing is off while
+// Endpoint is empty.
+type TracingConfig struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, e.g.
+	// http://localhost:4318. Spans are posted to its /v1/traces path.
+	Endpoint string `yaml:"endpoint"`
+	// Headers are sent with every export, for collectors that need
+	// authentication. Values may reference ${VAR}.
+	Headers map[string]string `yaml:"headers"`
+	// ServiceName is reported as service.name; defaults to arr-mcp.
+	ServiceName string `yaml:"serviceName"`
+}
+
+// AuditConfig enables the append-only JSONL log of mutating tool calls. The
+// log is off while Path is empty.
+type AuditConfig struct {
+	Path string `yaml:"path"`
+	// MaxSizeMB rotates the file once it would grow past this size.
+	MaxSizeMB int `yaml:"maxSizeMB"`
+	// MaxBackups is how many rotated files to keep as path.1, path.2, ...
+	MaxBackups int `yaml:"maxBackups"`
+}
+
+// AuthConfig lists the bearer credentials the HTTP transport accepts. With
+// neither tokens nor OAuth configured the transport is unauthenticated.
+type AuthConfig struct {
+	Tokens []Token      `yaml:"tokens"`
+	OAuth  *OAuthConfig `yaml:"oauth"`
+}
+
+// Enabled reports whether the HTTP transport requires a bearer credential.
+func (a AuthConfig) Enabled() bool {
+	return len(a.Tokens) > 0 || a.OAuth != nil
 }
 
-var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+// OAuthConfig makes /mcp an OAuth 2.1 protected resource that accepts JWT
+// access tokens issued by Issuer for Audience, signed by a key in JWKS.
+type OAuthConfig struct {
+	Issuer string `yaml:"issuer"`
+	// Audience is this server's canonical /mcp URL. Tokens must name it in
+	// their aud claim, and it is advertised as the protected resource.
+	Audience string `yaml:"audience"`
+	// JWKS is the issuer's key set: a local file path or an http(s) URL.
+	JWKS string `yaml:"jwks"`
+}
 
-// expandEnv replaces ${VAR} references with their environment values. An unset
-// variable is an error: an empty API key would otherwise surface much later as
-// a confusing 401 from the upstream service.
+// Token is one static bearer token. Its value comes from Token, which may be
+// a ${VAR} reference, or from the contents of TokenFile; exactly one is set.
+type Token struct {
+	Name      string `yaml:"name"`
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"tokenFile"`
+	// Permissions optionally overrides the instance and global policy for
+	// every call made with this token.
+	Permissions *Permissions `yaml:"permissions"`
+}
+
+// envRef matches ${VAR} and ${file:/path} references.
+var envRef = regexp.MustCompile(`\$\{(?:file:([^}]+)|([A-Za-z_][A-Za-z0-9_]*))\}`)
+
+// expandEnv replaces ${VAR} references with their environment values, and
+// ${file:/path} references with the contents of a mounted secret. An unset
+// variable, or a missing or empty file, is an error: an empty API key would
+// otherwise surface much later as a confusing 401 from the upstream service.
 func expandEnv(field, value string) (string, error) {
 	var missing []string
+	var fileErr error
 	out := envRef.ReplaceAllStringFunc(value, func(m string) string {
-		name := envRef.FindStringSubmatch(m)[1]
-		v, ok := os.LookupEnv(name)
+		ref := envRef.FindStringSubmatch(m)
+		if path := ref[1]; path != "" {
+			v, err := readSecretFile(path)
+			if err != nil && fileErr == nil {
+				fileErr = fmt.Errorf("%s: %w", field, err)
+			}
+			return v
+		}
+		v, ok := os.LookupEnv(ref[2])
 		if !ok || v == "" {
-			missing = append(missing, name)
+			missing = append(missing, ref[2])
 			return ""
 		}
 		return v
 	})
+	if fileErr != nil {
+		return "", fileErr
+	}
 	if len(missing) > 0 {
 		return "", fmt.Errorf("%s references unset environment variable(s): %s",
 			field, strings.Join(missing, ", "))
@@ -87,11 +256,32 @@ func expandEnv(field, value string) (string, error) {
 	return out, nil
 }
 
+// readSecretFile reads a secret mounted as a file, as Docker and Kubernetes
+// do, dropping the trailing newline most tools leave when writing one.
+func readSecretFile(path string) (string, error) {
+	// The path comes from the operator's own configuration or environment.
+	raw, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return "", fmt.Errorf("reading secret file: %w", err)
+	}
+	v := strings.TrimRight(string(raw), "\r\n")
+	if v == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return v, nil
+}
+
 // Load reads configuration from a YAML file. When path is empty it falls back
 // to building a single default instance per service from environment variables.
 func Load(path string) (*Config, error) {
 	c := &Config{
-		Server:      ServerConfig{Transport: "stdio", Addr: "0.0.0.0:8080", LogLevel: "info"},
+		Server: ServerConfig{
+			Transport: "stdio", Addr: "0.0.0.0:8080", LogLevel: "info",
+			Audit:     AuditConfig{MaxSizeMB: 10, MaxBackups: 5},
+			Tracing:   TracingConfig{ServiceName: "arr-mcp"},
+			Readiness: ReadinessConfig{Policy: ReadyAll, CacheTTL: 15 * time.Second},
+			Upstream:  DefaultUpstream,
+		},
 		Permissions: Permissions{Mode: ModeConfirm, ConfirmScope: ScopeWrite, Fallback: FallbackDeny},
 		Services:    map[string][]Instance{},
 	}
@@ -121,27 +311,39 @@ func Load(path string) (*Config, error) {
 	return c, nil
 }
 
-// loadFromEnv builds one instance per service from <SERVICE>_URL/<SERVICE>_API_KEY,
-// covering the single-instance docker-compose quickstart with no config file.
-func (c *Config) loadFromEnv() error {
-	for _, svc := range KnownServices {
-		prefix := strings.ToUpper(svc)
-		url := os.Getenv(prefix + "_URL")
-		key := os.Getenv(prefix + "_API_KEY")
-		if url == "" || key == "" {
-			continue
+// expand resolves ${VAR} references in every field that accepts them, and
+// reads bearer tokens kept in files.
+func (c *Config) expand() error {
+	for k, v := range c.Server.Tracing.Headers {
+		var err error
+		if c.Server.Tracing.Headers[k], err = expandEnv("server.tracing.headers."+k, v); err != nil {
+			return err
 		}
-		c.Services[svc] = []Instance{{Name: "default", URL: url, APIKey: key, Default: true}}
 	}
-	if len(c.Services) == 0 {
-		return fmt.Errorf("no configuration found: pass --config, or set <SERVICE>_URL and "+
-			"<SERVICE>_API_KEY for at least one of: %s", strings.Join(KnownServices, ", "))
+	if o := c.Server.Auth.OAuth; o != nil {
+		var err error
+		if o.JWKS, err = expandEnv("server.auth.oauth.jwks", o.JWKS); err != nil {
+			return err
+		}
+	}
+	for i := range c.Server.Auth.Tokens {
+		tok := &c.Server.Auth.Tokens[i]
+		field := "server.auth.tokens." + tok.Name
+		var err error
+		if tok.Token, err = expandEnv(field+".token", tok.Token); err != nil {
+			return err
+		}
+		if tok.TokenFile == "" {
+			continue
+		}
+		if tok.Token != "" {
+			return fmt.Errorf("%s: set token or tokenFile, not both", field)
+		}
+		if tok.Token, err = readSecretFile(tok.TokenFile); err != nil {
+			return fmt.Errorf("%s.tokenFile: %w", field, err)
+		}
 	}
-	return nil
-}
 
-// expand resolves ${VAR} references in every instance field that accepts them.
-func (c *Config) expand() error {
 	for svc, instances := range c.Services {
 		for i := range instances {
 			inst := &instances[i]
@@ -152,6 +354,32 @@ func (c *Config) expand() error {
 			if inst.APIKey, err = expandEnv(fmt.Sprintf("%s.%s.apiKey", svc, inst.Name), inst.APIKey); err != nil {
 				return err
 			}
+			for k, v := range inst.Headers {
+				if inst.Headers[k], err = expandEnv(fmt.Sprintf("%s.%s.headers.%s", svc, inst.Name, k), v); err != nil {
+					return err
+				}
+			}
+			if b := inst.BasicAuth; b != nil {
+				field := fmt.Sprintf("%s.%s.basicAuth", svc, inst.Name)
+				if b.Username, err = expandEnv(field+".username", b.Username); err != nil {
+					return err
+				}
+				if b.Password, err = expandEnv(field+".password", b.Password); err != nil {
+					return err
+				}
+			}
+			if t := inst.TLS; t != nil {
+				field := fmt.Sprintf("%s.%s.tls", svc, inst.Name)
+				if t.CAFile, err = expandEnv(field+".caFile", t.CAFile); err != nil {
+					return err
+				}
+				if t.CertFile, err = expandEnv(field+".certFile", t.CertFile); err != nil {
+					return err
+				}
+				if t.KeyFile, err = expandEnv(field+".keyFile", t.KeyFile); err != nil {
+					return err
+				}
+			}
 		}
 		c.Services[svc] = instances
 	}
@@ -164,6 +392,37 @@ func (c *Config) validate() error {
 		return err
 	}
 
+	if err := c.Server.Auth.validate(); err != nil {
+		return err
+	}
+	if e := c.Server.Tracing.Endpoint; e != "" {
+		if u, err := url.Parse(e); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("server.tracing.endpoint %q must be an http(s) URL such as http://localhost:4318", e)
+		}
+	}
+	if c.Server.Readiness.Policy == "" {
+		c.Server.Readiness.Policy = ReadyAll
+	}
+	switch r := c.Server.Readiness; {
+	case r.Policy != ReadyAll && r.Policy != ReadyAny:
+		return fmt.Errorf("server.readiness.policy: unknown policy %q; want one of: %s, %s",
+			r.Policy, ReadyAll, ReadyAny)
+	case r.CacheTTL < 0:
+		return fmt.Errorf("server.readiness.cacheTTL cannot be negative")
+	}
+	switch u := c.Server.Upstream; {
+	case u.Retries < 0 || u.RetryBaseDelay < 0 || u.RetryMaxDelay < 0:
+		return fmt.Errorf("server.upstream: retries, retryBaseDelay and retryMaxDelay cannot be negative")
+	case u.RetryMaxDelay < u.RetryBaseDelay:
+		return fmt.Errorf("server.upstream.retryMaxDelay %s is shorter than retryBaseDelay %s",
+			u.RetryMaxDelay, u.RetryBaseDelay)
+	case u.BreakerThreshold < 0 || u.BreakerCooldown < 0:
+		return fmt.Errorf("server.upstream: breakerThreshold and breakerCooldown cannot be negative")
+	}
+	if c.Server.Audit.MaxSizeMB < 0 || c.Server.Audit.MaxBackups < 0 {
+		return fmt.Errorf("server.audit: maxSizeMB and maxBackups cannot be negative")
+	}
+
 	known := map[string]bool{}
 	for _, s := range KnownServices {
 		known[s] = true
@@ -199,6 +458,18 @@ func (c *Config) validate() error {
 			if inst.APIKey == "" {
 				return fmt.Errorf("%s.%s: missing apiKey", svc, inst.Name)
 			}
+			if err := validateProxyAuth(inst, fmt.Sprintf("%s.%s", svc, inst.Name)); err != nil {
+				return err
+			}
+			if inst.TLS != nil {
+				field := fmt.Sprintf("%s.%s.tls", svc, inst.Name)
+				if u, err := url.Parse(inst.URL); err != nil || u.Scheme != "https" {
+					return fmt.Errorf("%s: TLS settings need an https url, got %q", field, inst.URL)
+				}
+				if err := inst.TLS.load(field); err != nil {
+					return err
+				}
+			}
 			if inst.Default {
 				defaults++
 			}
@@ -208,12 +479,25 @@ func (c *Config) validate() error {
 					return err
 				}
 			}
+			if err := validateCommands(svc, inst.Commands, fmt.Sprintf("%s.%s.commands", svc, inst.Name)); err != nil {
+				return err
+			}
 		}
 		if defaults > 1 {
 			return fmt.Errorf("%s: only one instance may be marked default", svc)
 		}
 	}
 
+	for svc, list := range c.Commands {
+		if !known[svc] {
+			return fmt.Errorf("commands: unknown service %q; supported services: %s",
+				svc, strings.Join(KnownServices, ", "))
+		}
+		if err := validateCommands(svc, list, "commands."+svc); err != nil {
+			return err
+		}
+	}
+
 	if len(c.Services) == 0 {
 		return fmt.Errorf("no services configured; supported services: %s",
 			strings.Join(KnownServices, ", "))
@@ -221,6 +505,106 @@ func (c *Config) validate() error {
 	return nil
 }
 
+// validateCommands checks a run_command allowlist for service against
+// KnownCommands, so a misspelt name fails here rather than quietly leaving the
+// command out. Names match whatever their case and are rewritten in the
+// service's own casing, the one the run_command schema offers.
+func validateCommands(service string, list []string, field string) error {
+	if list == nil {
+		return nil
+	}
+	// Bazarr has no /command endpoint, so it has no run_command tool to limit.
+	if service == "bazarr" {
+		return fmt.Errorf("%s: bazarr has no commands to allow", field)
+	}
+	known := KnownCommands[service]
+	for i, name := range list {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%s[%d]: empty command name", field, i)
+		}
+		j := slices.IndexFunc(known, func(k string) bool { return strings.EqualFold(k, strings.TrimSpace(name)) })
+		if j < 0 {
+			return fmt.Errorf("%s[%d]: %s has no command %q; known commands: %s",
+				field, i, service, name, strings.Join(known, ", "))
+		}
+		list[i] = known[j]
+	}
+	return nil
+}
+
+// validateProxyAuth rejects extra headers and basic credentials that would
+// clash with the API key, or with each other.
+func validateProxyAuth(inst *Instance, field string) error {
+	for k := range inst.Headers {
+		name := textproto.CanonicalMIMEHeaderKey(k)
+		switch {
+		case k == "" || strings.ContainsAny(k, " \t\r\n:"):
+			return fmt.Errorf("%s.headers: %q is not a valid header name", field, k)
+		case name == "X-Api-Key":
+			return fmt.Errorf("%s.headers: %s is sent from apiKey and cannot be set here", field, k)
+		case name == "Authorization" && inst.BasicAuth != nil:
+			return fmt.Errorf("%s: set basicAuth or an Authorization header, not both", field)
+		}
+	}
+	if b := inst.BasicAuth; b != nil && (b.Username == "" || b.Password == "") {
+		return fmt.Errorf("%s.basicAuth: username and password are both required", field)
+	}
+	return nil
+}
+
+// validate rejects tokens that could never authenticate or that would be
+// indistinguishable from each other, and incomplete OAuth settings.
+func (a *AuthConfig) validate() error {
+	if a.OAuth != nil {
+		if err := a.OAuth.validate(); err != nil {
+			return err
+		}
+	}
+
+	names := map[string]bool{}
+	values := map[string]bool{}
+	for i := range a.Tokens {
+		tok := &a.Tokens[i]
+		if tok.Name == "" {
+			return fmt.Errorf("server.auth.tokens: every token needs a name")
+		}
+		if names[tok.Name] {
+			return fmt.Errorf("server.auth.tokens: duplicate token name %q", tok.Name)
+		}
+		names[tok.Name] = true
+
+		field := "server.auth.tokens." + tok.Name
+		if tok.Token == "" {
+			return fmt.Errorf("%s: missing token or tokenFile", field)
+		}
+		if values[tok.Token] {
+			return fmt.Errorf("%s: token value is shared with another token", field)
+		}
+		values[tok.Token] = true
+		if tok.Permissions != nil {
+			if err := validatePermissions(tok.Permissions, field+".permissions"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate requires every setting, since a token can only be trusted once its
+// issuer, audience and signing key have all been checked.
+func (o *OAuthConfig) validate() error {
+	for _, f := range []struct{ field, value string }{{"issuer", o.Issuer}, {"audience", o.Audience}} {
+		u, err := url.Parse(f.value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("server.auth.oauth.%s: want an absolute URL, got %q", f.field, f.value)
+		}
+	}
+	if o.JWKS == "" {
+		return fmt.Errorf("server.auth.oauth.jwks: missing key set file or URL")
+	}
+	return nil
+}
+
 // validatePermissions checks a permission block and fills unset fields with the
 // same defaults Load applies globally.
 func validatePermissions(p *Permissions, field string) error {
@@ -250,5 +634,5 @@ func validatePermissions(p *Permissions, field string) error {
 		return fmt.Errorf("%s.fallback: unknown fallback %q; want one of: %s, %s",
 			field, p.Fallback, FallbackDeny, FallbackAllow)
 	}
-	return nil
+	return validateToolRules(p, field)
 }
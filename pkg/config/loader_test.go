@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -240,9 +243,526 @@ func TestLoadFromEnvBuildsDefaultInstance(t *testing.T) {
 	}
 }
 
+// writeSecret writes a secret file the way a Docker or Kubernetes mount
+// presents one, trailing newline included.
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing secret: %v", err)
+	}
+	return p
+}
+
+func TestLoadFromEnvReadsAPIKeyFile(t *testing.T) {
+	t.Setenv("SONARR_URL", "http://envhost:8989")
+	t.Setenv("SONARR_API_KEY_FILE", writeSecret(t, "filekey\n"))
+
+	c, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if inst, _ := c.Resolve("sonarr", ""); inst.APIKey != "filekey" {
+		t.Errorf("api key = %q, want the file contents without the newline", inst.APIKey)
+	}
+
+	t.Setenv("SONARR_API_KEY", "envkey")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "not both") {
+		t.Errorf("err = %v, want both the key and the file rejected", err)
+	}
+}
+
+func TestLoadFromEnvRejectsEmptyAPIKeyFile(t *testing.T) {
+	t.Setenv("SONARR_URL", "http://envhost:8989")
+	t.Setenv("SONARR_API_KEY_FILE", writeSecret(t, "\n"))
+
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "SONARR_API_KEY_FILE") {
+		t.Errorf("err = %v, want the empty file reported", err)
+	}
+}
+
+func TestFileReferencesReadMountedSecrets(t *testing.T) {
+	key := writeSecret(t, "mounted-key\r\n")
+	c, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: ${file:`+key+`}
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := c.Services["sonarr"][0].APIKey; got != "mounted-key" {
+		t.Errorf("api key = %q, want the file contents without the line ending", got)
+	}
+
+	for name, ref := range map[string]string{
+		"missing": "${file:" + filepath.Join(t.TempDir(), "absent") + "}",
+		"empty":   "${file:" + writeSecret(t, "") + "}",
+	} {
+		_, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: `+ref+`
+`))
+		if err == nil || !strings.Contains(err.Error(), "sonarr.main.apiKey") {
+			t.Errorf("%s file: err = %v, want an error naming sonarr.main.apiKey", name, err)
+		}
+	}
+}
+
 func TestLoadWithNoConfigAndNoEnvFails(t *testing.T) {
 	_, err := Load("")
 	if err == nil {
 		t.Fatal("expected an error when nothing is configured, got nil")
 	}
 }
+
+func TestLoadAcceptsLidarr(t *testing.T) {
+	p := writeCfg(t, `
+services:
+  lidarr:
+    - name: main
+      url: http://a:8686
+      apiKey: k
+`)
+
+	c, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := c.ConfiguredServices(); len(got) != 1 || got[0] != "lidarr" {
+		t.Errorf("configured services = %v, want [lidarr]", got)
+	}
+}
+
+func TestLoadFromEnvAcceptsReadarr(t *testing.T) {
+	t.Setenv("READARR_URL", "http://books:8787")
+	t.Setenv("READARR_API_KEY", "bookkey")
+
+	c, err := Load("")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	inst, err := c.Resolve("readarr", "")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if inst.Name != "default" || inst.URL != "http://books:8787" || inst.APIKey != "bookkey" {
+		t.Errorf("instance = %+v, want the default readarr instance from env", inst)
+	}
+}
+
+func TestAuthTokensResolveFromEnvAndFile(t *testing.T) {
+	t.Setenv("LAPTOP_TOKEN", "from-env")
+	file := filepath.Join(t.TempDir(), "agent-token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+	p := writeCfg(t, `
+server:
+  auth:
+    tokens:
+      - name: laptop
+        token: ${LAPTOP_TOKEN}
+      - name: agent
+        tokenFile: `+file+`
+        permissions:
+          mode: readonly
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`)
+
+	c, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := c.Server.Auth.Token("laptop"); got == nil || got.Token != "from-env" {
+		t.Errorf("laptop token = %+v, want value from env", got)
+	}
+	agent := c.Server.Auth.Token("agent")
+	if agent == nil || agent.Token != "from-file" {
+		t.Fatalf("agent token = %+v, want trimmed file contents", agent)
+	}
+
+	main, _ := c.Resolve("sonarr", "main")
+	if got := c.CallerPermissions(main, agent).Mode; got != ModeReadOnly {
+		t.Errorf("agent mode = %q, want the token override %q", got, ModeReadOnly)
+	}
+	if got := c.CallerPermissions(main, c.Server.Auth.Token("laptop")).Mode; got != ModeConfirm {
+		t.Errorf("laptop mode = %q, want the global default %q", got, ModeConfirm)
+	}
+}
+
+// Token files follow the same rule as every other mounted secret.
+func TestAuthTokenFilesReadLikeOtherSecrets(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(writeCfg(t, `
+server:
+  auth:
+    tokens:
+      - name: agent
+        tokenFile: `+empty+`
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`))
+	if err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("Load err = %v, want the empty secret file reported", err)
+	}
+
+	padded := filepath.Join(dir, "padded")
+	if err := os.WriteFile(padded, []byte("  tok  \r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(writeCfg(t, `
+server:
+  auth:
+    tokens:
+      - name: agent
+        tokenFile: `+padded+`
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := c.Server.Auth.Token("agent").Token; got != "  tok  " {
+		t.Errorf("token = %q, want only the line ending dropped", got)
+	}
+}
+
+func TestAuthTokensRejectAmbiguousConfig(t *testing.T) {
+	cases := map[string]string{
+		"both sources": `
+      - name: a
+        token: x
+        tokenFile: /dev/null`,
+		"duplicate name": `
+      - name: a
+        token: x
+      - name: a
+        token: y`,
+		"shared value": `
+      - name: a
+        token: x
+      - name: b
+        token: x`,
+		"no value": `
+      - name: a`,
+	}
+	for name, tokens := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := writeCfg(t, `
+server:
+  auth:
+    tokens:`+tokens+`
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`)
+			if _, err := Load(p); err == nil {
+				t.Error("expected Load to reject the token config")
+			}
+		})
+	}
+}
+
+func TestOAuthRequiresIssuerAudienceAndKeySet(t *testing.T) {
+	base := `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+server:
+  auth:
+    oauth:`
+	valid := `
+      issuer: https://id.example.test/realm
+      audience: https://arr.example.test/mcp
+      jwks: https://id.example.test/realm/certs`
+
+	c, err := Load(writeCfg(t, base+valid))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !c.Server.Auth.Enabled() || c.Server.Auth.OAuth.Audience != "https://arr.example.test/mcp" {
+		t.Errorf("auth = %+v, want OAuth enabled", c.Server.Auth)
+	}
+
+	for name, body := range map[string]string{
+		"relative issuer": strings.Replace(valid, "https://id.example.test/realm\n", "realm\n", 1),
+		"no audience":     strings.Replace(valid, "audience: https://arr.example.test/mcp", "", 1),
+		"no jwks":         strings.Replace(valid, "jwks: https://id.example.test/realm/certs", "", 1),
+	} {
+		if _, err := Load(writeCfg(t, base+body)); err == nil {
+			t.Errorf("%s: expected Load to reject the oauth config", name)
+		}
+	}
+}
+
+func TestAuditDefaultsRotationAndRejectsNegativeLimits(t *testing.T) {
+	base := `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+server:
+  audit:
+    path: /var/log/arr-mcp/audit.jsonl`
+
+	c, err := Load(writeCfg(t, base))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if a := c.Server.Audit; a.MaxSizeMB != 10 || a.MaxBackups != 5 {
+		t.Errorf("audit = %+v, want maxSizeMB=10 maxBackups=5 by default", a)
+	}
+
+	if _, err := Load(writeCfg(t, base+"\n    maxBackups: -1")); err == nil {
+		t.Error("expected Load to reject a negative maxBackups")
+	}
+}
+
+func TestTracingExpandsHeadersAndValidatesEndpoint(t *testing.T) {
+	t.Setenv("COLLECTOR_TOKEN", "abc")
+	base := `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+server:
+  tracing:`
+
+	c, err := Load(writeCfg(t, base+`
+    endpoint: http://localhost:4318
+    headers:
+      Authorization: Bearer ${COLLECTOR_TOKEN}`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	tr := c.Server.Tracing
+	if tr.Headers["Authorization"] != "Bearer abc" || tr.ServiceName != "arr-mcp" {
+		t.Errorf("tracing = %+v, want the expanded header and default service name", tr)
+	}
+
+	if _, err := Load(writeCfg(t, base+`
+    endpoint: localhost:4318`)); err == nil {
+		t.Error("expected Load to reject an endpoint without a scheme")
+	}
+}
+
+func TestReadinessDefaultsAndRejectsUnknownPolicy(t *testing.T) {
+	base := `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`
+	c, err := Load(writeCfg(t, base))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if r := c.Server.Readiness; r.Policy != ReadyAll || r.CacheTTL <= 0 {
+		t.Errorf("readiness = %+v, want policy all with a positive cache TTL", r)
+	}
+
+	c, err = Load(writeCfg(t, base+`server:
+  readiness:
+    policy: any
+    cacheTTL: 30s
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if r := c.Server.Readiness; r.Policy != ReadyAny || r.CacheTTL.Seconds() != 30 {
+		t.Errorf("readiness = %+v, want policy any cached for 30s", r)
+	}
+
+	if _, err := Load(writeCfg(t, base+`server:
+  readiness:
+    policy: most
+`)); err == nil {
+		t.Error("expected Load to reject an unknown readiness policy")
+	}
+}
+
+func TestUpstreamPolicyDefaultsAndOverrides(t *testing.T) {
+	base := `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`
+	c, err := Load(writeCfg(t, base))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if c.Server.Upstream != DefaultUpstream {
+		t.Errorf("upstream = %+v, want the defaults", c.Server.Upstream)
+	}
+
+	c, err = Load(writeCfg(t, base+`server:
+  upstream:
+    retries: 0
+    breakerThreshold: 3
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if u := c.Server.Upstream; u.Retries != 0 || u.BreakerThreshold != 3 || u.BreakerCooldown != DefaultUpstream.BreakerCooldown {
+		t.Errorf("upstream = %+v, want retries off and threshold 3 with the default cooldown", u)
+	}
+
+	for _, bad := range []string{"retries: -1", "retryMaxDelay: 10ms", "breakerCooldown: -1s"} {
+		if _, err := Load(writeCfg(t, base+"server:\n  upstream:\n    "+bad+"\n")); err == nil {
+			t.Errorf("expected Load to reject %q", bad)
+		}
+	}
+}
+
+func TestDryRunLoadsFromServer(t *testing.T) {
+	c, err := Load(writeCfg(t, `
+server:
+  dryRun: true
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !c.Server.DryRun {
+		t.Error("server.dryRun not loaded")
+	}
+}
+
+func TestProxyHeadersAndBasicAuthExpandEnv(t *testing.T) {
+	t.Setenv("CF_SECRET", "cf-secret")
+	t.Setenv("PROXY_PASSWORD", "pw")
+	c, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: main
+      url: https://sonarr.example.com
+      apiKey: k
+      headers:
+        CF-Access-Client-Id: client-id
+        CF-Access-Client-Secret: ${CF_SECRET}
+      basicAuth:
+        username: arr
+        password: ${PROXY_PASSWORD}
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	inst := c.Services["sonarr"][0]
+	if got := inst.Headers["CF-Access-Client-Secret"]; got != "cf-secret" {
+		t.Errorf("header = %q, want the expanded secret", got)
+	}
+	if b := inst.BasicAuth; b == nil || b.Username != "arr" || b.Password != "pw" {
+		t.Errorf("basicAuth = %+v, want arr with the expanded password", b)
+	}
+}
+
+func TestProxyHeadersAndBasicAuthAreValidated(t *testing.T) {
+	for _, tc := range []struct{ name, block, want string }{
+		{"api key header", "      headers:\n        x-api-key: other\n", "sent from apiKey"},
+		{"bad name", "      headers:\n        \"Bad Header\": v\n", "not a valid header name"},
+		{"both authorizations", "      headers:\n        Authorization: Bearer t\n      basicAuth:\n        username: u\n        password: p\n", "not both"},
+		{"no password", "      basicAuth:\n        username: u\n", "both required"},
+	} {
+		_, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`+tc.block))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+// Allowlists match whatever the case and keep the service's spelling, the
+// one the run_command schema offers.
+func TestCommandAllowlistsTakeTheServicesCasing(t *testing.T) {
+	c, err := Load(writeCfg(t, `
+services:
+  lidarr:
+    - name: main
+      url: http://a:8686
+      apiKey: k
+commands:
+  lidarr: [rsssync, RESCANFOLDERS]
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := c.Commands["lidarr"]; !slices.Equal(got, []string{"RssSync", "RescanFolders"}) {
+		t.Errorf("commands = %v, want RssSync and RescanFolders", got)
+	}
+}
+
+func TestCommandAllowlistsLoadAndAreValidated(t *testing.T) {
+	c, err := Load(writeCfg(t, `
+services:
+  lidarr:
+    - name: main
+      url: http://a:8686
+      apiKey: k
+      commands: [RssSync, Backup]
+commands:
+  lidarr: [RssSync]
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := c.Services["lidarr"][0].Commands; len(got) != 2 || c.Commands["lidarr"][0] != "RssSync" {
+		t.Errorf("commands = %v and %v, want both lists loaded", got, c.Commands)
+	}
+
+	for _, tc := range []struct{ name, block, want string }{
+		{"unknown service", "commands:\n  plex: [Scan]\n", `commands: unknown service "plex"`},
+		{"bazarr", "commands:\n  bazarr: [Sync]\n", "bazarr has no commands"},
+		{"empty name", "commands:\n  sonarr: [RssSync, \" \"]\n", "commands.sonarr[1]: empty command name"},
+		{"typo", "commands:\n  sonarr: [RssSync, RefreshSeris]\n", `commands.sonarr[1]: sonarr has no command "RefreshSeris"`},
+		{"other service's command", "commands:\n  sonarr: [RescanFolders]\n", `sonarr has no command "RescanFolders"`},
+	} {
+		_, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: main
+      url: http://a:8989
+      apiKey: k
+`+tc.block))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
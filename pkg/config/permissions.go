@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ForTool resolves the policy governing one tool, reporting false if the
+// tool rules exclude it. The result carries no tool rules of its own. An
+// empty name, as for resources and prompts, is governed by mode alone.
+//
+// Tool rules name tools by glob pattern, matched case-insensitively with the
+// syntax of path.Match: sonarr_delete_* or *_run_command. A tool whose
+// behaviour hinges on one argument is also named as tool:argument, such as
+// sonarr_run_command:RssSync. A pattern without an argument covers every
+// argument of the tools it matches.
+//
+// Deny wins over allow. Where several patterns in tools cover the name the
+// longest, most specific, one applies, and between equally long patterns the
+// stricter mode. A per-tool confirm confirms that tool whatever confirmScope
+// says, since naming it is a request to be asked.
+func (p Permissions) ForTool(name string) (Permissions, bool) {
+	out := Permissions{Mode: p.Mode, ConfirmScope: p.ConfirmScope, Fallback: p.Fallback}
+	if name == "" {
+		return out, true
+	}
+	for _, pattern := range p.DenyTools {
+		if covers(pattern, name) {
+			return out, false
+		}
+	}
+	if len(p.AllowTools) > 0 && !p.allows(name) {
+		return out, false
+	}
+
+	best := ""
+	for pattern, mode := range p.Tools {
+		if !covers(pattern, name) {
+			continue
+		}
+		if best == "" || len(pattern) > len(best) ||
+			(len(pattern) == len(best) && strictness(mode) > strictness(p.Tools[best])) {
+			best = pattern
+		}
+	}
+	if best != "" {
+		out.Mode = p.Tools[best]
+		if out.Mode == ModeConfirm {
+			out.ConfirmScope = ScopeWrite
+		}
+	}
+	return out, true
+}
+
+// allows reports whether an allowTools pattern covers name. A pattern naming
+// one argument also admits its bare tool, so the tool is advertised and the
+// argument checked when it is called.
+func (p Permissions) allows(name string) bool {
+	for _, pattern := range p.AllowTools {
+		if covers(pattern, name) {
+			return true
+		}
+		if tool, _, qualified := strings.Cut(pattern, ":"); qualified && !strings.Contains(name, ":") && covers(tool, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether pattern matches name, or matches the tool of a
+// tool:argument name when the pattern names no argument itself.
+func covers(pattern, name string) bool {
+	pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	tool, _, qualified := strings.Cut(name, ":")
+	if !qualified || strings.Contains(pattern, ":") {
+		return false
+	}
+	ok, _ := path.Match(pattern, tool)
+	return ok
+}
+
+// ArgumentModes returns the modes that tools patterns naming an argument
+// give tool, so a tool that is readonly in general can still be advertised
+// when one of its arguments is not.
+func (p Permissions) ArgumentModes(tool string) []Mode {
+	var out []Mode
+	for pattern, mode := range p.Tools {
+		if t, _, qualified := strings.Cut(pattern, ":"); qualified && covers(t, tool) {
+			out = append(out, mode)
+		}
+	}
+	return out
+}
+
+// strictness orders modes from most to least permissive.
+func strictness(m Mode) int {
+	switch m {
+	case ModeReadOnly:
+		return 2
+	case ModeConfirm:
+		return 1
+	}
+	return 0
+}
+
+// UnmatchedToolPatterns lists, sorted, the tool rule patterns that cover
+// none of tools. Such a pattern is usually a typo, and one in denyTools
+// leaves allowed what it was meant to refuse.
+func (p Permissions) UnmatchedToolPatterns(tools []string) []string {
+	patterns := append(append([]string{}, p.AllowTools...), p.DenyTools...)
+	for pattern := range p.Tools {
+		patterns = append(patterns, pattern)
+	}
+	var out []string
+	for _, pattern := range patterns {
+		tool, _, _ := strings.Cut(pattern, ":")
+		if !slices.ContainsFunc(tools, func(name string) bool { return covers(tool, name) }) {
+			out = append(out, pattern)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// validateToolRules checks the patterns and per-tool modes of a permission
+// block.
+func validateToolRules(p *Permissions, field string) error {
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"allowTools", p.AllowTools}, {"denyTools", p.DenyTools}} {
+		for i, pattern := range list.patterns {
+			if err := validatePattern(pattern); err != nil {
+				return fmt.Errorf("%s.%s[%d]: %w", field, list.name, i, err)
+			}
+		}
+	}
+	for pattern, mode := range p.Tools {
+		if err := validatePattern(pattern); err != nil {
+			return fmt.Errorf("%s.tools[%q]: %w", field, pattern, err)
+		}
+		switch mode {
+		case ModeReadOnly, ModeConfirm, ModeFull:
+		default:
+			return fmt.Errorf("%s.tools[%q]: unknown mode %q; want one of: %s, %s, %s",
+				field, pattern, mode, ModeReadOnly, ModeConfirm, ModeFull)
+		}
+	}
+	return nil
+}
+
+func validatePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("empty pattern")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("pattern %q: %w", pattern, err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForToolResolvesToolRules(t *testing.T) {
+	p := Permissions{
+		Mode: ModeConfirm, ConfirmScope: ScopeDestructive, Fallback: FallbackDeny,
+		DenyTools: []string{"*_run_command:Backup", "prowlarr_*"},
+		Tools: map[string]Mode{
+			"sonarr_*":                    ModeFull,
+			"sonarr_delete_*":             ModeReadOnly,
+			"sonarr_delete_episode_files": ModeConfirm,
+			"*_run_command:rsssync":       ModeFull,
+		},
+	}
+	for _, tc := range []struct {
+		tool    string
+		want    Mode
+		allowed bool
+	}{
+		{"sonarr_add_series", ModeFull, true},
+		{"sonarr_delete_series", ModeReadOnly, true},
+		{"sonarr_delete_episode_files", ModeConfirm, true},
+		{"radarr_add_movie", ModeConfirm, true},
+		{"radarr_run_command:RssSync", ModeFull, true},
+		{"radarr_run_command:BACKUP", "", false},
+		{"radarr_run_command", ModeConfirm, true},
+		{"prowlarr_search", "", false},
+		{"", ModeConfirm, true},
+	} {
+		got, ok := p.ForTool(tc.tool)
+		if ok != tc.allowed || (ok && got.Mode != tc.want) {
+			t.Errorf("ForTool(%q) = %s, %v; want %s, %v", tc.tool, got.Mode, ok, tc.want, tc.allowed)
+		}
+		if len(got.Tools) != 0 || len(got.DenyTools) != 0 {
+			t.Errorf("ForTool(%q) kept the tool rules", tc.tool)
+		}
+	}
+
+	// Naming a tool for confirmation asks even outside confirmScope.
+	if got, _ := p.ForTool("sonarr_delete_episode_files"); got.ConfirmScope != ScopeWrite {
+		t.Errorf("confirmScope = %s for a tool set to confirm, want write", got.ConfirmScope)
+	}
+}
+
+func TestAllowToolsLimitsToolsAndAdmitsCommandTools(t *testing.T) {
+	p := Permissions{Mode: ModeFull, AllowTools: []string{"*_list_*", "sonarr_run_command:RssSync"}}
+	for tool, want := range map[string]bool{
+		"radarr_list_movies":           true,
+		"sonarr_run_command":           true,
+		"sonarr_run_command:rsssync":   true,
+		"sonarr_run_command:Backup":    false,
+		"sonarr_add_series":            false,
+		"sonarr_delete_series":         false,
+		"radarr_run_command":           false,
+		"radarr_list_movies:something": true,
+	} {
+		if _, ok := p.ForTool(tool); ok != want {
+			t.Errorf("ForTool(%q) allowed = %v, want %v", tool, ok, want)
+		}
+	}
+}
+
+func TestEquallySpecificPatternsTakeTheStricterMode(t *testing.T) {
+	p := Permissions{Mode: ModeFull, Tools: map[string]Mode{
+		"sonarr_delete_*": ModeFull,
+		"*_delete_series": ModeReadOnly,
+	}}
+	for range 20 {
+		if got, _ := p.ForTool("sonarr_delete_series"); got.Mode != ModeReadOnly {
+			t.Fatalf("mode = %s, want readonly", got.Mode)
+		}
+	}
+}
+
+func TestToolRulesAreValidated(t *testing.T) {
+	for _, tc := range []struct {
+		name, perms, want string
+	}{
+		{"bad glob", "  denyTools: [\"sonarr_[\"]\n", "permissions.denyTools[0]"},
+		{"empty", "  allowTools: [\"\"]\n", "empty pattern"},
+		{"bad mode", "  tools:\n    sonarr_delete_*: never\n", `permissions.tools["sonarr_delete_*"]: unknown mode`},
+	} {
+		_, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: main
+      url: http://sonarr:8989
+      apiKey: k
+permissions:
+`+tc.perms))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestToolRulesLoadPerInstance(t *testing.T) {
+	c, err := Load(writeCfg(t, `
+services:
+  sonarr:
+    - name: main
+      url: http://sonarr:8989
+      apiKey: k
+      permissions:
+        mode: full
+        denyTools: [sonarr_delete_*]
+        tools:
+          "sonarr_run_command:Backup": readonly
+`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	p := c.EffectivePermissions(&c.Services["sonarr"][0])
+	if _, ok := p.ForTool("sonarr_delete_series"); ok {
+		t.Error("instance denyTools not applied")
+	}
+	if got, _ := p.ForTool("sonarr_run_command:Backup"); got.Mode != ModeReadOnly {
+		t.Errorf("Backup mode = %s, want readonly", got.Mode)
+	}
+}
+
+func TestUnmatchedToolPatterns(t *testing.T) {
+	p := Permissions{
+		AllowTools: []string{"sonarr_*"},
+		DenyTools:  []string{"sonarr_delete_serie", "*_run_command:Backup"},
+		Tools:      map[string]Mode{"radar_*": ModeFull},
+	}
+	got := p.UnmatchedToolPatterns([]string{"sonarr_delete_series", "radarr_run_command"})
+	if want := []string{"radar_*", "sonarr_delete_serie"}; !slices.Equal(got, want) {
+		t.Errorf("unmatched = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// TLSConfig adjusts how the connection to an https instance is secured: a
+// private CA to trust, a client certificate for ingresses that require mTLS,
+// and the name to verify when the URL uses an address the certificate does
+// not carry.
+type TLSConfig struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string `yaml:"caFile"`
+	// CertFile and KeyFile hold the PEM client certificate and its key; set
+	// both or neither.
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// ServerName is verified instead of the URL's host.
+	ServerName string `yaml:"serverName"`
+	// InsecureSkipVerify accepts any certificate. It exists for lab setups and
+	// is warned about at startup.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+
+	client *tls.Config
+}
+
+// ClientConfig returns the settings Load prepared, with certificates already
+// read. It returns nil, meaning Go's defaults, for a nil t or one that did not
+// come from Load.
+func (t *TLSConfig) ClientConfig() *tls.Config {
+	if t == nil {
+		return nil
+	}
+	return t.client
+}
+
+// Equal reports whether t and o secure a connection the same way: the same
+// settings and the same certificates read from their files. Each Load
+// allocates its own TLSConfig, so a reload that leaves the block untouched
+// still compares equal, while a certificate replaced in place does not.
+func (t *TLSConfig) Equal(o *TLSConfig) bool {
+	if t == nil || o == nil {
+		return t == o
+	}
+	if t.CAFile != o.CAFile || t.CertFile != o.CertFile || t.KeyFile != o.KeyFile ||
+		t.ServerName != o.ServerName || t.InsecureSkipVerify != o.InsecureSkipVerify {
+		return false
+	}
+	a, b := t.client, o.client
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.RootCAs.Equal(b.RootCAs) && slices.EqualFunc(a.Certificates, b.Certificates, func(x, y tls.Certificate) bool {
+		return slices.EqualFunc(x.Certificate, y.Certificate, bytes.Equal)
+	})
+}
+
+// load reads the certificate files and prepares the client configuration,
+// so a missing or malformed file stops startup rather than the first call.
+func (t *TLSConfig) load(field string) error {
+	if t.InsecureSkipVerify && t.CAFile != "" {
+		return fmt.Errorf("%s: set caFile or insecureSkipVerify, not both", field)
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("%s: certFile and keyFile must be set together", field)
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: t.ServerName,
+		// #nosec G402 -- only when the operator opts in, and warned about
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		// Like --config, certificate paths are chosen by the operator.
+		pem, err := os.ReadFile(t.CAFile) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("%s.caFile: %w", field, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s.caFile: no PEM certificates found in %s", field, t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return fmt.Errorf("%s: loading client certificate: %w", field, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	t.client = cfg
+	return nil
+}
+
+// InsecureInstances lists, as service/instance, every instance whose
+// certificate is not verified.
+func (c *Config) InsecureInstances() []string {
+	var out []string
+	for _, svc := range c.ConfiguredServices() {
+		for _, inst := range c.Services[svc] {
+			if inst.TLS != nil && inst.TLS.InsecureSkipVerify {
+				out = append(out, svc+"/"+inst.Name)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate and its key as PEM files,
+// returning their paths.
+func writeCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "arr-mcp test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// tlsCfg configures one Sonarr instance at url with the given tls block.
+func tlsCfg(url, tls string) string {
+	return `
+services:
+  sonarr:
+    - name: main
+      url: ` + url + `
+      apiKey: k
+      tls:
+` + tls
+}
+
+func TestTLSSettingsAreLoadedUpFront(t *testing.T) {
+	cert, key := writeCert(t)
+	t.Setenv("ARR_TEST_CA", cert)
+	c, err := Load(writeCfg(t, tlsCfg("https://sonarr.internal", `
+        caFile: ${ARR_TEST_CA}
+        certFile: `+cert+`
+        keyFile: `+key+`
+        serverName: sonarr.example.com
+`)))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	tc := c.Services["sonarr"][0].TLS
+	if tc.CAFile != cert {
+		t.Errorf("caFile = %q, want the expanded path", tc.CAFile)
+	}
+	got := tc.ClientConfig()
+	if got == nil || got.RootCAs == nil || len(got.Certificates) != 1 || got.ServerName != "sonarr.example.com" {
+		t.Errorf("client config = %+v, want the CA, client certificate and server name", got)
+	}
+	if got.InsecureSkipVerify {
+		t.Error("verification disabled without being asked")
+	}
+	if names := c.InsecureInstances(); len(names) != 0 {
+		t.Errorf("insecure instances = %v, want none", names)
+	}
+}
+
+func TestTLSSettingsAreValidated(t *testing.T) {
+	cert, key := writeCert(t)
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name, url, tls, want string
+	}{
+		{"plain http", "http://sonarr:8989", "        caFile: " + cert, "https url"},
+		{"cert without key", "https://sonarr", "        certFile: " + cert, "set together"},
+		{"missing CA", "https://sonarr", "        caFile: /nonexistent/ca.pem", "caFile"},
+		{"not PEM", "https://sonarr", "        caFile: " + garbage, "no PEM certificates"},
+		{"key mismatch", "https://sonarr", "        certFile: " + cert + "\n        keyFile: " + garbage, "client certificate"},
+		{"CA and insecure", "https://sonarr", "        caFile: " + key + "\n        insecureSkipVerify: true", "not both"},
+	} {
+		_, err := Load(writeCfg(t, tlsCfg(tc.url, tc.tls+"\n")))
+		if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.Contains(err.Error(), "sonarr.main.tls") {
+			t.Errorf("%s: err = %v, want sonarr.main.tls ... %s", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestTLSEqualComparesSettingsAndCertificates(t *testing.T) {
+	cert, key := writeCert(t)
+	path := writeCfg(t, tlsCfg("https://sonarr", "        certFile: "+cert+"\n        keyFile: "+key+"\n"))
+	load := func() *TLSConfig {
+		t.Helper()
+		c, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load returned error: %v", err)
+		}
+		return c.Services["sonarr"][0].TLS
+	}
+
+	first, again := load(), load()
+	if first == again || !first.Equal(again) {
+		t.Error("loading the same settings twice did not compare equal")
+	}
+	if first.Equal(nil) || !(*TLSConfig)(nil).Equal(nil) {
+		t.Error("nil settings compared wrongly")
+	}
+
+	// Replace the certificate in place, as a renewal would.
+	newCert, newKey := writeCert(t)
+	for src, dst := range map[string]string{newCert: cert, newKey: key} {
+		b, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dst, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.Equal(load()) {
+		t.Error("a renewed certificate compared equal to the old one")
+	}
+}
+
+func TestInsecureInstancesAreReported(t *testing.T) {
+	c, err := Load(writeCfg(t, tlsCfg("https://sonarr", "        insecureSkipVerify: true\n")))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !c.Services["sonarr"][0].TLS.ClientConfig().InsecureSkipVerify {
+		t.Error("insecureSkipVerify not applied")
+	}
+	if names := c.InsecureInstances(); len(names) != 1 || names[0] != "sonarr/main" {
+		t.Errorf("insecure instances = %v, want [sonarr/main]", names)
+	}
+}
@@ -80,13 +80,12 @@ func (l *Logger) SetOutput(w io.Writer) {
 
 // log logs a message at the specified level
 func (l *Logger) log(level Level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if level < l.level {
 		return
 	}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
 	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
 	message := fmt.Sprintf(format, args...)
 	logEntry := fmt.Sprintf("%s [%s] %s: %s\n", timestamp, levelStrings[level], l.prefix, message)
@@ -0,0 +1,263 @@
+// Package metrics collects counters and latency histograms and serves them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics exported by arr-mcp. They live in Default so packages can record
+// without threading a registry through every constructor.
+var (
+	// ToolCalls counts and times tool calls. outcome is ok, error, or the
+	// permission decision that refused the call (denied, declined).
+	ToolCalls = Default.Histogram("arrmcp_tool_call_duration_seconds",
+		"Tool call latency by tool, instance and outcome.", "tool", "instance", "outcome")
+	// Upstream times every HTTP request to a service. code is the response
+	// status, or "error" when no response arrived.
+	Upstream = Default.Histogram("arrmcp_upstream_request_duration_seconds",
+		"Latency of HTTP requests to upstream services by service, method and status code.",
+		"service", "method", "code")
+	// UpstreamRetries counts requests repeated after a transient failure.
+	UpstreamRetries = Default.Counter("arrmcp_upstream_retries_total",
+		"Upstream requests retried after a transient failure, by service and method.", "service", "method")
+	// BreakerRejections counts calls failed fast because the instance's
+	// circuit breaker was open.
+	BreakerRejections = Default.Counter("arrmcp_upstream_breaker_rejections_total",
+		"Upstream requests refused without being sent because the circuit breaker was open, by service.",
+		"service")
+	// PermissionDecisions counts mutating calls the permission gate refused,
+	// or let through only after asking or by fallback.
+	PermissionDecisions = Default.Counter("arrmcp_permission_decisions_total",
+		"Permission gate decisions other than a plain allow, by tool and outcome.", "tool", "outcome")
+	// Elicitations times confirmation prompts sent to the client. result is
+	// the user's action, or "error" when the round-trip failed.
+	Elicitations = Default.Histogram("arrmcp_elicitation_duration_seconds",
+		"Round-trip time of elicitation prompts by result.", "result")
+	// ActiveSessions is the number of connected MCP sessions.
+	ActiveSessions = Default.Gauge("arrmcp_active_sessions", "Connected MCP sessions.")
+)
+
+// Buckets are the histogram upper bounds in seconds. They stretch past the
+// usual ten seconds because command tools may wait for a minute or more.
+var Buckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Default is the registry the exported metrics belong to.
+var Default = &Registry{}
+
+// Registry holds metric families in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+type family interface {
+	write(w io.Writer)
+}
+
+// Counter registers a monotonically increasing counter family.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec: newVec(name, help, labels)}
+	r.add(c)
+	return c
+}
+
+// Histogram registers a latency histogram family using Buckets.
+func (r *Registry) Histogram(name, help string, labels ...string) *Histogram {
+	h := &Histogram{vec: newVec(name, help, labels)}
+	r.add(h)
+	return h
+}
+
+// Gauge registers a single unlabelled gauge.
+func (r *Registry) Gauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	r.add(g)
+	return g
+}
+
+func (r *Registry) add(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// Write writes every family in the text exposition format.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+// Handler serves the registry. before, when non-nil, runs ahead of each
+// scrape so callers can refresh gauges sampled on demand.
+func (r *Registry) Handler(before func()) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if before != nil {
+			before()
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// vec is the label bookkeeping shared by counters and histograms.
+type vec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	values  []string
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{name: name, help: help, labels: labels, series: map[string]*series{}}
+}
+
+// get returns the series for values, creating it on first use. The caller
+// must hold v.mu.
+func (v *vec) get(values []string) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted snapshots the series in label order, so scrapes are stable.
+func (v *vec) sorted() []series {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	out := make([]series, 0, len(v.series))
+	for _, s := range v.series {
+		cp := *s
+		cp.buckets = append([]uint64(nil), s.buckets...)
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].values, "\xff") < strings.Join(out[j].values, "\xff")
+	})
+	return out
+}
+
+func (v *vec) header(w io.Writer, kind string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, kind)
+}
+
+// labelSet renders {k="v",...}, appending extra pairs such as le.
+func (v *vec) labelSet(values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, val := range values {
+		pairs = append(pairs, v.labels[i]+`="`+escape.Replace(val)+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escape.Replace(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escape applies the only three escapes the exposition format defines.
+var escape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Counter counts events per label combination.
+type Counter struct{ vec }
+
+// Inc adds one to the series named by values, in label order.
+func (c *Counter) Inc(values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(values).count++
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.header(w, "counter")
+	for _, s := range c.sorted() {
+		_, _ = fmt.Fprintf(w, "%s%s %d\n", c.name, c.labelSet(s.values), s.count)
+	}
+}
+
+// Histogram records durations per label combination.
+type Histogram struct{ vec }
+
+// Observe records d against the series named by values, in label order.
+func (h *Histogram) Observe(d time.Duration, values ...string) {
+	secs := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(values)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(Buckets))
+	}
+	for i, le := range Buckets {
+		if secs <= le {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.sum += secs
+}
+
+// Since is Observe(time.Since(start), values...).
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start), values...)
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.header(w, "histogram")
+	for _, s := range h.sorted() {
+		for i, le := range Buckets {
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name,
+				h.labelSet(s.values, "le", strconv.FormatFloat(le, 'g', -1, 64)), s.buckets[i])
+		}
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelSet(s.values, "le", "+Inf"), s.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %g\n", h.name, h.labelSet(s.values), s.sum)
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelSet(s.values), s.count)
+	}
+}
+
+// Gauge is a single value that can go up and down.
+type Gauge struct {
+	name, help string
+
+	mu    sync.Mutex
+	value float64
+}
+
+// Set replaces the gauge's value.
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value = v
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	v := g.value
+	g.mu.Unlock()
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", g.name, g.help, g.name, g.name, v)
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryWritesTextExposition(t *testing.T) {
+	r := &Registry{}
+	calls := r.Counter("test_calls_total", "Calls.", "tool")
+	latency := r.Histogram("test_latency_seconds", "Latency.", "code")
+	sessions := r.Gauge("test_sessions", "Sessions.")
+
+	calls.Inc("b")
+	calls.Inc("a")
+	calls.Inc("a")
+	latency.Observe(300*time.Millisecond, "200")
+	latency.Observe(3*time.Second, "200")
+
+	rec := httptest.NewRecorder()
+	r.Handler(func() { sessions.Set(2) }).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE test_calls_total counter\ntest_calls_total{tool=\"a\"} 2\ntest_calls_total{tool=\"b\"} 1\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{code="200",le="0.25"} 0` + "\n",
+		`test_latency_seconds_bucket{code="200",le="0.5"} 1` + "\n",
+		`test_latency_seconds_bucket{code="200",le="5"} 2` + "\n",
+		`test_latency_seconds_bucket{code="200",le="+Inf"} 2` + "\n",
+		`test_latency_seconds_sum{code="200"} 3.3` + "\n",
+		`test_latency_seconds_count{code="200"} 2` + "\n",
+		"# TYPE test_sessions gauge\ntest_sessions 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("exposition missing %q:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+}
+
+func TestLabelValuesAreEscaped(t *testing.T) {
+	r := &Registry{}
+	r.Counter("test_total", "Escaping.", "name").Inc("a\"b\\c\nd")
+
+	var sb strings.Builder
+	r.Write(&sb)
+	if want := `test_total{name="a\"b\\c\nd"} 1`; !strings.Contains(sb.String(), want) {
+		t.Errorf("exposition = %q, want it to contain %q", sb.String(), want)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/audit"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SetAuditLog records every mutating tool call to l. A nil log, the default,
+// records nothing.
+func (s *Server) SetAuditLog(l *audit.Log) { s.auditLog = l }
+
+// recordAudit appends one mutating call to the audit log. Reads are not
+// recorded: the log exists to answer who changed what. A write failure is
+// logged rather than returned, since the call itself has already happened.
+func (s *Server) recordAudit(
+	req *mcp.CallToolRequest, service string, inst *config.Instance, meta toolMeta,
+	in any, decision Outcome, status int, callErr error, start time.Time,
+) {
+	if s.auditLog == nil || meta.access == AccessRead {
+		return
+	}
+	e := audit.Entry{
+		Time:           start.UTC(),
+		Tool:           meta.name,
+		Service:        service,
+		Instance:       inst.Name,
+		Access:         meta.access.String(),
+		Arguments:      audit.Redact(in),
+		Decision:       string(decision),
+		UpstreamStatus: status,
+		DurationMS:     time.Since(start).Milliseconds(),
+	}
+	switch {
+	case errors.Is(callErr, arr.ErrDryRun):
+		e.DryRun = true
+	case callErr != nil:
+		e.Error = callErr.Error()
+	}
+	if req.Session != nil {
+		e.Session = req.Session.ID()
+		if p := req.Session.InitializeParams(); p != nil && p.ClientInfo != nil {
+			e.Client = p.ClientInfo.Name + " " + p.ClientInfo.Version
+		}
+	}
+	if req.Extra != nil && req.Extra.TokenInfo != nil {
+		e.Caller = req.Extra.TokenInfo.UserID
+	}
+	if err := s.auditLog.Record(e); err != nil {
+		s.log.Error("audit: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/audit"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// auditedSession connects to a server recording into a fresh audit log and
+// returns the log's path.
+func auditedSession(t *testing.T, cfg *config.Config) (*mcp.ClientSession, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := audit.Open(config.AuditConfig{Path: path})
+	if err != nil {
+		t.Fatalf("audit.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	s := New(cfg, logger.New("error", "test"))
+	s.SetAuditLog(l)
+	return connectServer(t, s, nil), path
+}
+
+func auditEntries(t *testing.T, path string) []audit.Entry {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	var out []audit.Entry
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		if line == "" {
+			continue
+		}
+		var e audit.Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("audit line %q: %v", line, err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestAuditRecordsWritesButNotReads(t *testing.T) {
+	srv, _ := fakeArr(t, `{"id":4,"label":"kids"}`)
+	cs, path := auditedSession(t, mediaCfg(srv.URL))
+	ctx := context.Background()
+
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "radarr_list_tags"}); err != nil {
+		t.Fatalf("CallTool list: %v", err)
+	}
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "radarr_create_tag",
+		Arguments: map[string]any{"label": "kids"},
+	})
+	if err != nil {
+		t.Fatalf("CallTool create: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+
+	got := auditEntries(t, path)
+	if len(got) != 1 {
+		t.Fatalf("audit entries = %+v, want only the write", got)
+	}
+	e := got[0]
+	if e.Tool != "radarr_create_tag" || e.Service != "radarr" || e.Instance != "main" || e.Access != "write" {
+		t.Errorf("entry = %+v, want radarr_create_tag on radarr/main", e)
+	}
+	if e.Decision != string(OutcomeAllowed) || e.UpstreamStatus != 200 || e.Error != "" {
+		t.Errorf("entry = %+v, want an allowed call that returned 200", e)
+	}
+	if e.Arguments["label"] != "kids" || e.Client != "test v0" {
+		t.Errorf("entry = %+v, want the arguments and client recorded", e)
+	}
+}
+
+func TestAuditRecordsRefusedCalls(t *testing.T) {
+	srv, hits := fakeArr(t, `{}`)
+	cfg := mediaCfg(srv.URL)
+	cfg.Permissions = config.Permissions{Mode: config.ModeConfirm, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny}
+	cs, path := auditedSession(t, cfg)
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "radarr_create_tag",
+		Arguments: map[string]any{"label": "kids"},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if !res.IsError || *hits != 0 {
+		t.Fatalf("expected the call to be refused before reaching radarr")
+	}
+
+	got := auditEntries(t, path)
+	if len(got) != 1 || got[0].Decision != string(OutcomeDenied) || got[0].Error == "" {
+		t.Errorf("audit entries = %+v, want one denied call with its error", got)
+	}
+}
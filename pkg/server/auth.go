@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Keys under which requireAuth records how a request authenticated, in the
+// TokenInfo the SDK hands to every handler.
+const (
+	staticTokenKey = "arr-mcp/static-token"
+	oauthTokenKey  = "arr-mcp/oauth"
+)
+
+// requireAuth rejects requests that carry neither a configured static token
+// nor a valid OAuth access token with 401. Each credential gets a distinct
+// user id, so the SDK also refuses to let one resume another's session.
+func (s *Server) requireAuth() func(http.Handler) http.Handler {
+	verify := func(ctx context.Context, presented string, _ *http.Request) (*auth.TokenInfo, error) {
+		// Read per request, so tokens added or revoked by a reload apply at
+		// once.
+		a := s.config().Server.Auth
+		// Compare against every static token so the time taken does not
+		// reveal which, if any, came close.
+		var match *config.Token
+		for i := range a.Tokens {
+			if subtle.ConstantTimeCompare([]byte(presented), []byte(a.Tokens[i].Token)) == 1 {
+				match = &a.Tokens[i]
+			}
+		}
+		if match != nil {
+			return &auth.TokenInfo{UserID: "token:" + match.Name, Extra: map[string]any{staticTokenKey: match}}, nil
+		}
+		if s.oauth != nil {
+			return s.oauth.verify(ctx, presented)
+		}
+		return nil, fmt.Errorf("%w: unrecognised bearer token", auth.ErrInvalidToken)
+	}
+
+	// Static tokens have no expiry of their own; revoking one means removing
+	// it from the config. JWTs without exp are refused by the verifier.
+	opts := &auth.RequireBearerTokenOptions{AllowMissingExpiration: true}
+	if o := s.config().Server.Auth.OAuth; o != nil {
+		opts.ResourceMetadataURL = resourceMetadataURL(o)
+	}
+	return auth.RequireBearerToken(verify, opts)
+}
+
+// callerToken returns the configured static token a request authenticated
+// with, or nil for OAuth callers and unauthenticated transports such as stdio.
+func (s *Server) callerToken(extra *mcp.RequestExtra) *config.Token {
+	if extra == nil || extra.TokenInfo == nil {
+		return nil
+	}
+	tok, _ := extra.TokenInfo.Extra[staticTokenKey].(*config.Token)
+	return tok
+}
+
+// callerScopes returns the OAuth scopes a request was granted, or nil when it
+// did not authenticate with OAuth and so is not limited by scope.
+func callerScopes(extra *mcp.RequestExtra) []string {
+	if extra == nil || extra.TokenInfo == nil || extra.TokenInfo.Extra[oauthTokenKey] != true {
+		return nil
+	}
+	return extra.TokenInfo.Scopes
+}
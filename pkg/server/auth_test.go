@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// bearer adds an Authorization header to every request it forwards.
+type bearer string
+
+func (b bearer) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+string(b))
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// serveHTTP starts the server's HTTP handler and returns its base URL.
+func serveHTTP(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+	srv := httptest.NewServer(New(cfg, logger.New("error", "test")).HTTPHandler())
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// connectHTTP opens an MCP session over Streamable HTTP presenting token.
+func connectHTTP(t *testing.T, base, token string) *mcp.ClientSession {
+	t.Helper()
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v0"}, nil).Connect(
+		context.Background(), &mcp.StreamableClientTransport{
+			Endpoint:   base + "/mcp",
+			HTTPClient: &http.Client{Transport: bearer(token)},
+		}, nil)
+	if err != nil {
+		t.Fatalf("connect with token %q: %v", token, err)
+	}
+	t.Cleanup(func() { _ = cs.Close() })
+	return cs
+}
+
+func authCfg(upstream string, perms config.Permissions, tokens ...config.Token) *config.Config {
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: upstream, APIKey: "k", Default: true}},
+	}, perms)
+	cfg.Server.Auth.Tokens = tokens
+	return cfg
+}
+
+func TestHTTPRejectsMissingOrWrongTokenButLeavesHealthOpen(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	base := serveHTTP(t, authCfg(srv.URL, permsFull, config.Token{Name: "laptop", Token: "s3cret"}))
+
+	for _, header := range []string{"", "Bearer wrong"} {
+		req, _ := http.NewRequest(http.MethodPost, base+"/mcp", strings.NewReader(`{}`))
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /mcp: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", header, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(base + "/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("/health status = %d, want 200 without a token", resp.StatusCode)
+	}
+
+	if names := toolNames(t, connectHTTP(t, base, "s3cret")); !has(names, "sonarr_list_series") {
+		t.Errorf("authenticated session sees no sonarr tools: %v", names)
+	}
+}
+
+// A token's policy overrides the instance policy in both directions.
+func TestTokenPermissionsOverrideInstancePolicy(t *testing.T) {
+	srv, hits := fakeArr(t, `{"id":1,"label":"4k"}`)
+	readonly := config.Permissions{Mode: config.ModeReadOnly, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny}
+	base := serveHTTP(t, authCfg(srv.URL, readonly,
+		config.Token{Name: "admin", Token: "admin-token", Permissions: &permsFull},
+		config.Token{Name: "viewer", Token: "viewer-token"},
+	))
+
+	create := &mcp.CallToolParams{Name: "sonarr_create_tag", Arguments: map[string]any{"label": "4k"}}
+
+	res, err := connectHTTP(t, base, "admin-token").CallTool(context.Background(), create)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("admin token was refused a write: %s", contentText(res))
+	}
+	if *hits != 1 {
+		t.Errorf("upstream hits = %d after admin write, want 1", *hits)
+	}
+
+	res, err = connectHTTP(t, base, "viewer-token").CallTool(context.Background(), create)
+	if err == nil && !res.IsError {
+		t.Fatal("viewer token without an override was allowed to write under a readonly instance")
+	}
+	if *hits != 1 {
+		t.Errorf("upstream contacted for a refused write: hits = %d", *hits)
+	}
+}
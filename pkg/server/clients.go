@@ -0,0 +1,126 @@
+package server
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+)
+
+// clientPool keeps one long-lived client per configured instance, so tool
+// calls reuse open connections instead of handshaking with the instance, or
+// the TLS reverse proxy in front of it, every time.
+type clientPool struct {
+	// policy is the retry and circuit breaker policy pooled clients follow.
+	// Because a client keeps its breaker, an instance that keeps failing is
+	// cut off across tool calls rather than within one.
+	policy config.UpstreamConfig
+
+	mu      sync.Mutex
+	clients map[string]pooledClient
+}
+
+// pooledClient remembers the settings a client was built from, so a changed
+// instance gets a fresh client rather than one pointing at the old address.
+type pooledClient struct {
+	built  config.Instance
+	client *arr.Client
+}
+
+// snapshot copies the connection settings of inst, so later edits to the
+// original cannot go unnoticed.
+func snapshot(inst *config.Instance) config.Instance {
+	cp := *inst
+	cp.Headers = maps.Clone(inst.Headers)
+	if inst.BasicAuth != nil {
+		b := *inst.BasicAuth
+		cp.BasicAuth = &b
+	}
+	return cp
+}
+
+// current reports whether inst still connects the way the client was built to.
+func (pc pooledClient) current(inst *config.Instance) bool {
+	b := pc.built
+	if b.URL != inst.URL || b.APIKey != inst.APIKey || !b.TLS.Equal(inst.TLS) || !maps.Equal(b.Headers, inst.Headers) {
+		return false
+	}
+	if b.BasicAuth == nil || inst.BasicAuth == nil {
+		return b.BasicAuth == inst.BasicAuth
+	}
+	return *b.BasicAuth == *inst.BasicAuth
+}
+
+// get returns the shared client for inst, building it on first use or when
+// the instance's address, credentials or TLS settings have changed since.
+func (p *clientPool) get(service string, spec arr.ServiceSpec, inst *config.Instance) *arr.Client {
+	key := service + "/" + inst.Name
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pc, ok := p.clients[key]; ok {
+		if pc.current(inst) {
+			return pc.client
+		}
+		pc.client.CloseIdleConnections()
+	}
+	if p.clients == nil {
+		p.clients = map[string]pooledClient{}
+	}
+	c := newClient(spec, inst).WithPolicy(retryPolicy(p.policy))
+	p.clients[key] = pooledClient{built: snapshot(inst), client: c}
+	return c
+}
+
+// setPolicy changes the policy future clients follow. A changed policy drops
+// the pooled clients, so every instance picks it up on its next call.
+func (p *clientPool) setPolicy(policy config.UpstreamConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if policy == p.policy {
+		return
+	}
+	p.policy = policy
+	for _, pc := range p.clients {
+		pc.client.CloseIdleConnections()
+	}
+	p.clients = nil
+}
+
+// retain drops the clients of instances cfg no longer configures, closing
+// their idle connections, so a reload that removes an instance does not keep
+// its client for the life of the process.
+func (p *clientPool) retain(cfg *config.Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, pc := range p.clients {
+		service, name, _ := strings.Cut(key, "/")
+		if !slices.ContainsFunc(cfg.Services[service], func(inst config.Instance) bool { return inst.Name == name }) {
+			pc.client.CloseIdleConnections()
+			delete(p.clients, key)
+		}
+	}
+}
+
+// retryPolicy converts the configured upstream policy to the client's.
+func retryPolicy(u config.UpstreamConfig) arr.RetryPolicy {
+	return arr.RetryPolicy{
+		Retries:          u.Retries,
+		RetryBaseDelay:   u.RetryBaseDelay,
+		RetryMaxDelay:    u.RetryMaxDelay,
+		BreakerThreshold: u.BreakerThreshold,
+		BreakerCooldown:  u.BreakerCooldown,
+	}
+}
+
+// newClient builds a client for inst with its credentials, proxy headers and
+// TLS settings.
+func newClient(spec arr.ServiceSpec, inst *config.Instance) *arr.Client {
+	creds := arr.Credentials{APIKey: inst.APIKey, Headers: inst.Headers}
+	if b := inst.BasicAuth; b != nil {
+		creds.Username, creds.Password = b.Username, b.Password
+	}
+	return arr.NewClient(inst.URL, spec, creds).WithTLS(inst.TLS.ClientConfig())
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestClientPoolSharesClientsUntilTheInstanceChanges(t *testing.T) {
+	var p clientPool
+	inst := config.Instance{Name: "main", URL: "http://a:8989", APIKey: "k1"}
+
+	first := p.get("sonarr", arr.SonarrSpec, &inst)
+	if again := p.get("sonarr", arr.SonarrSpec, &inst); again != first {
+		t.Error("second call built a new client, want the pooled one")
+	}
+	if other := p.get("radarr", arr.RadarrSpec, &inst); other == first {
+		t.Error("an instance of another service shares the sonarr client")
+	}
+
+	inst.APIKey = "k2"
+	rotated := p.get("sonarr", arr.SonarrSpec, &inst)
+	if rotated == first {
+		t.Error("a changed API key kept the old client")
+	}
+
+	inst.Headers = map[string]string{"CF-Access-Client-Secret": "s1"}
+	withHeader := p.get("sonarr", arr.SonarrSpec, &inst)
+	if withHeader == rotated {
+		t.Error("an added proxy header kept the old client")
+	}
+	inst.Headers["CF-Access-Client-Secret"] = "s2"
+	if p.get("sonarr", arr.SonarrSpec, &inst) == withHeader {
+		t.Error("a header changed in place kept the old client")
+	}
+
+	inst.BasicAuth = &config.BasicAuth{Username: "u", Password: "p1"}
+	withBasic := p.get("sonarr", arr.SonarrSpec, &inst)
+	inst.BasicAuth.Password = "p2"
+	if p.get("sonarr", arr.SonarrSpec, &inst) == withBasic {
+		t.Error("a changed basic auth password kept the old client")
+	}
+}
+
+// Every reload allocates new TLS settings; unchanged ones keep the client and
+// its open connections.
+func TestClientPoolKeepsTheClientAcrossAnUnchangedTLSReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(serverName string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(`
+services:
+  sonarr:
+    - name: main
+      url: https://sonarr
+      apiKey: k
+      tls:
+        serverName: `+serverName+`
+`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() *config.Instance {
+		t.Helper()
+		cfg, err := config.Load(path)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return &cfg.Services["sonarr"][0]
+	}
+
+	var p clientPool
+	write("sonarr.internal")
+	first := p.get("sonarr", arr.SonarrSpec, load())
+	if p.get("sonarr", arr.SonarrSpec, load()) != first {
+		t.Error("reloading unchanged TLS settings built a new client")
+	}
+	write("sonarr.example")
+	if p.get("sonarr", arr.SonarrSpec, load()) == first {
+		t.Error("a changed server name kept the old client")
+	}
+}
+
+func TestClientPoolDropsInstancesNoLongerConfigured(t *testing.T) {
+	var p clientPool
+	main := config.Instance{Name: "main", URL: "http://a:8989", APIKey: "k"}
+	anime := config.Instance{Name: "anime", URL: "http://b:8989", APIKey: "k"}
+	kept := p.get("sonarr", arr.SonarrSpec, &main)
+	p.get("sonarr", arr.SonarrSpec, &anime)
+	p.get("radarr", arr.RadarrSpec, &main)
+
+	p.retain(cfgWith(map[string][]config.Instance{"sonarr": {main}}, permsFull))
+	if len(p.clients) != 1 {
+		t.Errorf("pool holds %d clients, want only sonarr/main", len(p.clients))
+	}
+	if p.get("sonarr", arr.SonarrSpec, &main) != kept {
+		t.Error("the instance still configured lost its client")
+	}
+}
+
+// Tool calls reach the instance through its auth proxy settings.
+func TestToolCallsSendProxyCredentials(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(srv.Close)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{
+			Name: "main", URL: srv.URL, APIKey: "k", Default: true,
+			Headers:   map[string]string{"CF-Access-Client-Id": "id"},
+			BasicAuth: &config.BasicAuth{Username: "u", Password: "p"},
+		}},
+	}, permsFull))
+
+	if _, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "sonarr_list_series"}); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if got.Get("CF-Access-Client-Id") != "id" || got.Get("X-Api-Key") != "k" || !strings.HasPrefix(got.Get("Authorization"), "Basic ") {
+		t.Errorf("headers = %v, want the proxy header, basic auth and the API key", got)
+	}
+}
+
+// Pooled clients keep their response cache across tool calls; fresh skips it.
+func TestListToolsServeFromCacheUnlessFresh(t *testing.T) {
+	srv, hits := fakeArr(t, `[]`)
+	cs := connect(t, mediaCfg(srv.URL))
+	call := func(args map[string]any) {
+		t.Helper()
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "sonarr_list_series", Arguments: args})
+		if err != nil || res.IsError {
+			t.Fatalf("CallTool: %v", err)
+		}
+	}
+
+	call(nil)
+	call(nil)
+	if *hits != 1 {
+		t.Errorf("upstream hits = %d after two calls, want 1", *hits)
+	}
+	call(map[string]any{"fresh": true})
+	if *hits != 2 {
+		t.Errorf("upstream hits = %d, want fresh to reach the service", *hits)
+	}
+}
+
+// The breaker lives on the pooled client, so failures in one tool call cut
+// the instance off for the next.
+func TestOpenBreakerFailsLaterToolCallsFast(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+	cfg := mediaCfg(srv.URL)
+	cfg.Server.Upstream = config.UpstreamConfig{BreakerThreshold: 1, BreakerCooldown: time.Minute}
+	cs := connect(t, cfg)
+
+	if _, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "sonarr_list_series"}); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "sonarr_list_series"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if !res.IsError || !strings.Contains(contentText(res), "instance unavailable") {
+		t.Errorf("result = %q, want an instance unavailable error", contentText(res))
+	}
+	if hits != 1 {
+		t.Errorf("upstream hits = %d, want only the call that opened the breaker", hits)
+	}
+}
+
+// The configuration's default and the client's must not drift apart, or a
+// client built outside the pool would follow a different policy.
+func TestDefaultUpstreamMatchesTheClientDefault(t *testing.T) {
+	if got := retryPolicy(config.DefaultUpstream); got != arr.DefaultRetryPolicy {
+		t.Errorf("retryPolicy(config.DefaultUpstream) = %+v, want %+v", got, arr.DefaultRetryPolicy)
+	}
+}
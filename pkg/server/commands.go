@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxCommandWait caps how long a tool call may block on a command. Searches and
+// library scans can run for far longer; past this the caller gets the command
+// back still running and can check on it with command_status.
+const maxCommandWait = 5 * time.Minute
+
+// commandPollInterval is how often a waiting tool re-reads the command. It is
+// a variable so tests need not sleep for real.
+var commandPollInterval = 2 * time.Second
+
+// allowedCommands merges the commands every instance of service allows, in
+// configuration order, for the run_command schema.
+func allowedCommands(cfg *config.Config, service string, spec arr.ServiceSpec) []string {
+	var out []string
+	for i := range cfg.Services[service] {
+		for _, name := range cfg.AllowedCommands(service, &cfg.Services[service][i], spec.Commands) {
+			if !slices.Contains(out, name) {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
+
+// awaitCommand waits for cmd to finish when the caller asked to, reporting
+// each poll as an MCP progress notification. Hitting the wait deadline is not
+// an error: the command keeps running upstream, and the outcome says so.
+func awaitCommand(ctx context.Context, c *arr.Client, cmd arr.CommandResult, wait WaitArg) (CommandOutcome, error) {
+	limit := wait.duration()
+	if limit <= 0 || cmd.Finished() {
+		return CommandOutcome{CommandResult: cmd}, nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+	progress := progressFrom(ctx)
+	start := time.Now()
+	final, err := arr.WaitForCommand(waitCtx, c, cmd, commandPollInterval, func(cur arr.CommandResult) {
+		msg := fmt.Sprintf("%s is %s", cur.Name, cur.Status)
+		if cur.Message != "" {
+			msg += ": " + cur.Message
+		}
+		progress.report(ctx, time.Since(start).Seconds(), limit.Seconds(), msg)
+	})
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return CommandOutcome{CommandResult: final, StillRunning: true}, nil
+	}
+	if err != nil {
+		return CommandOutcome{}, err
+	}
+	return CommandOutcome{CommandResult: final}, nil
+}
+
+// progressKey carries a progressReporter in a tool call's context, so handlers
+// several calls below register can report without a request in scope.
+type progressKey struct{}
+
+// progressReporter sends progress notifications for one tool call. The zero
+// value reports nothing, which is what a call without a progress token gets.
+type progressReporter struct {
+	session *mcp.ServerSession
+	token   any
+}
+
+// withProgress attaches a reporter for req to ctx when the client asked for
+// progress by sending a token.
+func withProgress(ctx context.Context, req *mcp.CallToolRequest) context.Context {
+	if req == nil || req.Session == nil || req.Params == nil {
+		return ctx
+	}
+	token := req.Params.GetProgressToken()
+	if token == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, progressReporter{session: req.Session, token: token})
+}
+
+// progressFrom returns the reporter attached to ctx, or one that does nothing.
+func progressFrom(ctx context.Context) progressReporter {
+	p, _ := ctx.Value(progressKey{}).(progressReporter)
+	return p
+}
+
+// report sends one notification. Delivery is best effort: a client that drops
+// a progress update has lost nothing the final result will not tell it.
+func (p progressReporter) report(ctx context.Context, progress, total float64, message string) {
+	if p.session == nil {
+		return
+	}
+	_ = p.session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: p.token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	})
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// commandArr serves a command that is queued when posted and reports each of
+// states in turn on successive polls, holding the last one.
+func commandArr(t *testing.T, states ...string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			_, _ = w.Write([]byte(`{"id":9,"name":"RssSync","status":"queued"}`))
+			return
+		}
+		state := states[min(polls, len(states)-1)]
+		polls++
+		exception := ""
+		if state == "failed" {
+			exception = "indexer timed out"
+		}
+		_, _ = w.Write([]byte(`{"id":9,"name":"RssSync","status":"` + state + `",` +
+			`"duration":"00:00:01.5000000","exception":"` + exception + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func fastPolling(t *testing.T) {
+	t.Helper()
+	prev := commandPollInterval
+	commandPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { commandPollInterval = prev })
+}
+
+func TestRunCommandWaitReportsProgressAndTheFinalStatus(t *testing.T) {
+	fastPolling(t)
+	srv := commandArr(t, "started", "started", "completed")
+
+	var mu sync.Mutex
+	var messages []string
+	cs := connectWith(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull), &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, req.Params.Message)
+		},
+	})
+
+	params := &mcp.CallToolParams{Name: "sonarr_run_command", Arguments: map[string]any{"name": "RssSync", "wait": 30}}
+	params.SetProgressToken("tok")
+	res, err := cs.CallTool(context.Background(), params)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+	body := contentText(res)
+	if !strings.Contains(body, `"status":"completed"`) || !strings.Contains(body, `"duration":"00:00:01.5000000"`) {
+		t.Errorf("result = %s, want the completed status and its duration", body)
+	}
+	if strings.Contains(body, "stillRunning") {
+		t.Errorf("result = %s, a finished command is not still running", body)
+	}
+
+	// Notifications are delivered asynchronously; give the last one a moment.
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(messages)
+		mu.Unlock()
+		if n >= 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) < 3 || !strings.Contains(messages[len(messages)-1], "RssSync is completed") {
+		t.Errorf("progress messages = %q, want one per poll ending in completion", messages)
+	}
+}
+
+func TestCommandWaitReportsTheException(t *testing.T) {
+	fastPolling(t)
+	srv := commandArr(t, "failed")
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"radarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "radarr_command_status", Arguments: map[string]any{"id": 9, "wait": 10},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if body := contentText(res); !strings.Contains(body, `"status":"failed"`) || !strings.Contains(body, "indexer timed out") {
+		t.Errorf("result = %s, want the failure and its exception", body)
+	}
+}
+
+// A wait that runs out is not an error: the command is still going upstream.
+func TestCommandWaitDeadlineReturnsTheCommandStillRunning(t *testing.T) {
+	fastPolling(t)
+	srv := commandArr(t, "started")
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "sonarr_command_status", Arguments: map[string]any{"id": 9, "wait": 1},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+	if body := contentText(res); !strings.Contains(body, `"stillRunning":true`) || !strings.Contains(body, `"status":"started"`) {
+		t.Errorf("result = %s, want the command reported still running", body)
+	}
+}
+
+func TestCommandWithoutWaitReturnsImmediately(t *testing.T) {
+	srv, paths := recordingArr(t, `{"id":9,"name":"RssSync","status":"queued"}`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "sonarr_run_command", Arguments: map[string]any{"name": "RssSync"},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+	if len(*paths) != 1 || (*paths)[0] != "POST /api/v3/command" {
+		t.Errorf("upstream calls = %v, want only the POST", *paths)
+	}
+}
+
+func TestWaitIsCappedAtTheMaximum(t *testing.T) {
+	if got := (WaitArg{Wait: 86400}).duration(); got != maxCommandWait {
+		t.Errorf("duration = %v, want the %v cap", got, maxCommandWait)
+	}
+	if got := (WaitArg{}).duration(); got != 0 {
+		t.Errorf("duration = %v, want 0 when no wait is asked for", got)
+	}
+}
+
+// runCommandSchema returns the values the run_command tool advertises for name.
+func runCommandSchema(t *testing.T, cs *mcp.ClientSession, tool string) []any {
+	t.Helper()
+	res, err := cs.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	for _, tl := range res.Tools {
+		if tl.Name != tool {
+			continue
+		}
+		raw, _ := json.Marshal(tl.InputSchema)
+		var schema struct {
+			Properties map[string]struct {
+				Enum []any `json:"enum"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			t.Fatalf("decoding schema: %v", err)
+		}
+		return schema.Properties["name"].Enum
+	}
+	t.Fatalf("%s not advertised", tool)
+	return nil
+}
+
+func TestRunCommandAdvertisesKnownCommandsByDefault(t *testing.T) {
+	srv, _ := fakeArr(t, `{}`)
+	cs := connect(t, mediaCfg(srv.URL))
+
+	enum := runCommandSchema(t, cs, "sonarr_run_command")
+	if len(enum) != len(arr.SonarrSpec.Commands) || !slices.Contains(enum, any("RssSync")) {
+		t.Errorf("sonarr commands = %v, want the built-in list", enum)
+	}
+	if slices.Contains(runCommandSchema(t, cs, "radarr_run_command"), any("RefreshSeries")) {
+		t.Error("radarr offers a Sonarr-only command")
+	}
+}
+
+// Config validates allowlists against its own catalogue; it has to cover every
+// default a spec offers, and library-wide rescans stay out of the defaults.
+func TestDefaultCommandsAreKnownToConfig(t *testing.T) {
+	for _, spec := range arr.Specs {
+		for _, name := range spec.Commands {
+			if !slices.Contains(config.KnownCommands[spec.Name], name) {
+				t.Errorf("%s default command %s missing from config.KnownCommands", spec.Name, name)
+			}
+		}
+		if slices.Contains(spec.Commands, "RescanFolders") {
+			t.Errorf("%s runs RescanFolders by default", spec.Name)
+		}
+	}
+}
+
+func TestRunCommandRefusesCommandsOutsideTheInstanceAllowlist(t *testing.T) {
+	srv, paths := recordingArr(t, `{"id":9,"name":"RssSync","status":"queued"}`)
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {
+			{Name: "main", URL: srv.URL, APIKey: "k", Default: true},
+			{Name: "anime", URL: srv.URL, APIKey: "k", Commands: []string{"RssSync", "RescanSeries"}},
+		},
+	}, permsFull)
+	cfg.Commands = map[string][]string{"sonarr": {"RssSync"}}
+	cs := connect(t, cfg)
+
+	if enum := runCommandSchema(t, cs, "sonarr_run_command"); !slices.Equal(enum, []any{"RssSync", "RescanSeries"}) {
+		t.Errorf("advertised commands = %v, want the union of both instances' lists", enum)
+	}
+	call := func(instance, command string) *mcp.CallToolResult {
+		t.Helper()
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+			Name: "sonarr_run_command", Arguments: map[string]any{"instance": instance, "name": command},
+		})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		return res
+	}
+	if res := call("main", "RescanSeries"); !res.IsError || !strings.Contains(contentText(res), "allowed: RssSync") {
+		t.Errorf("RescanSeries on main = %s, want it refused with the allowed list", contentText(res))
+	}
+	if res := call("main", "RescanFolders"); !res.IsError {
+		t.Error("a command outside every allowlist was accepted")
+	}
+	if len(*paths) != 0 {
+		t.Fatalf("refused commands reached the service: %v", *paths)
+	}
+	if res := call("anime", "RescanSeries"); res.IsError {
+		t.Errorf("RescanSeries on anime refused: %s", contentText(res))
+	}
+}
+
+func TestEmptyCommandAllowlistHidesRunCommand(t *testing.T) {
+	srv, _ := fakeArr(t, `{}`)
+	cfg := mediaCfg(srv.URL)
+	cfg.Commands = map[string][]string{"radarr": {}}
+
+	names := toolNames(t, connect(t, cfg))
+	if has(names, "radarr_run_command") {
+		t.Error("radarr_run_command advertised with no commands allowed")
+	}
+	if !has(names, "sonarr_run_command") || !has(names, "radarr_command_status") {
+		t.Errorf("unrelated command tools missing from %v", names)
+	}
+}
@@ -2,15 +2,127 @@ package server
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/GauranshMathur/ARR_MCP/pkg/metrics"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // sessionConfirmer asks the connected MCP client to prompt its user, using the
-// protocol's elicitation capability.
+// protocol's elicitation capability. With a form the prompt shows the call's
+// arguments for the user to check and edit; without one it is a plain yes or
+// no.
+//
+// The prompt travels as a multi round-trip input request: the first call
+// returns it instead of a result, and the client calls the tool again with
+// the user's answer in responses. The SDK plays the client's part for clients
+// on protocol versions that predate this, eliciting directly.
 type sessionConfirmer struct {
-	session *mcp.ServerSession
+	session   *mcp.ServerSession
+	responses mcp.InputResponseMap
+	// state is the RequestState echoed back with responses: when the prompt
+	// was sent, signed with key over that time and binding. An answer only
+	// counts for the call the prompt was made for.
+	state string
+	// key signs state; without one every answer is refused.
+	key []byte
+	// binding identifies the call: see confirmBinding.
+	binding string
+	form    *confirmForm
+}
+
+// confirmRequestID names the confirmation among a call's input requests.
+const confirmRequestID = "confirm"
+
+// confirmTTL is how long after the prompt an answer is still accepted.
+const confirmTTL = 10 * time.Minute
+
+// errConfirmState refuses an answer that arrives without the state of a
+// prompt for this very call, whether forged, stale or from another call.
+var errConfirmState = errors.New("the answer does not belong to a confirmation prompt for this call; " +
+	"call the tool again without inputResponses to be asked")
+
+// newConfirmKey returns a random key for signing confirmation state. It lives
+// as long as the process, so a restart invalidates outstanding prompts.
+func newConfirmKey() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key) // never fails
+	return key
+}
+
+// confirmBinding identifies a tool call by tool, instance and arguments, the
+// last in canonical JSON so that a retry sending the same values matches
+// whatever the order of its keys.
+func confirmBinding(tool, service, instance string, args json.RawMessage) string {
+	var canonical any
+	// The arguments have already passed the input schema, so they decode.
+	_ = json.Unmarshal(args, &canonical)
+	b, _ := json.Marshal(canonical)
+	return strings.Join([]string{tool, service, instance, string(b)}, "\x00")
+}
+
+// sign returns the RequestState for a prompt sent at sent.
+func (c sessionConfirmer) sign(sent time.Time) string {
+	ts := strconv.FormatInt(sent.UnixNano(), 10)
+	return ts + "." + base64.RawURLEncoding.EncodeToString(c.mac(ts))
+}
+
+func (c sessionConfirmer) mac(ts string) []byte {
+	m := hmac.New(sha256.New, c.key)
+	m.Write([]byte(ts))
+	m.Write([]byte{0})
+	m.Write([]byte(c.binding))
+	return m.Sum(nil)
+}
+
+// verify checks that state was signed for this call within confirmTTL and
+// returns when the prompt was sent.
+func (c sessionConfirmer) verify() (time.Time, error) {
+	ts, sig, ok := strings.Cut(c.state, ".")
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if !ok || err != nil || len(c.key) == 0 || !hmac.Equal(got, c.mac(ts)) {
+		return time.Time{}, errConfirmState
+	}
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, errConfirmState
+	}
+	sent := time.Unix(0, n)
+	if time.Since(sent) > confirmTTL {
+		return time.Time{}, fmt.Errorf("the confirmation prompt was answered after more than %s; call the tool again", confirmTTL)
+	}
+	return sent, nil
+}
+
+// confirmPending is returned by Confirm while the user has not yet answered:
+// the tool call returns params as an input request and is decided when the
+// client calls again with state.
+type confirmPending struct {
+	params *mcp.ElicitParams
+	state  string
+}
+
+func (p *confirmPending) Error() string { return ErrConfirmPending.Error() }
+
+func (p *confirmPending) Unwrap() error { return ErrConfirmPending }
+
+// result is the input-required result asking the client to prompt its user.
+func (p *confirmPending) result() *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		InputRequests: mcp.InputRequestMap{confirmRequestID: p.params},
+		RequestState:  p.state,
+	}
 }
 
 // Confirm implements Confirmer against a live MCP session.
@@ -22,15 +134,39 @@ func (c sessionConfirmer) Confirm(ctx context.Context, prompt string) (bool, err
 	if init == nil || init.Capabilities == nil || init.Capabilities.Elicitation == nil {
 		return false, ErrConfirmUnsupported
 	}
+	if caps := init.Capabilities.Elicitation; caps.Form == nil && caps.URL != nil {
+		return false, ErrConfirmUnsupported
+	}
 
-	res, err := c.session.Elicit(ctx, &mcp.ElicitParams{
-		Mode:    "confirm",
-		Message: prompt,
-	})
+	params := &mcp.ElicitParams{
+		Mode:            "form",
+		Message:         prompt,
+		RequestedSchema: &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{}},
+	}
+	if c.form != nil {
+		var err error
+		if params, err = c.form.params(ctx, prompt); err != nil {
+			return false, err
+		}
+	}
+	answer, ok := c.responses[confirmRequestID]
+	if !ok {
+		return false, &confirmPending{params: params, state: c.sign(time.Now())}
+	}
+	sent, err := c.verify()
 	if err != nil {
-		return false, fmt.Errorf("eliciting confirmation: %w", err)
+		return false, err
 	}
-	return interpretElicit(res.Action)
+	res, ok := answer.(*mcp.ElicitResult)
+	if !ok {
+		return false, fmt.Errorf("answer to the confirmation prompt is a %T, not an elicitation result", answer)
+	}
+	metrics.Elicitations.Since(sent, res.Action)
+	approved, err := interpretElicit(res.Action)
+	if !approved || err != nil || c.form == nil {
+		return approved, err
+	}
+	return true, c.form.accept(res.Content)
 }
 
 // interpretElicit maps an elicitation action onto an approval decision.
@@ -45,3 +181,190 @@ func interpretElicit(action string) (bool, error) {
 		return false, fmt.Errorf("unrecognised elicitation action %q", action)
 	}
 }
+
+// confirmField is the form property holding the confirmation a destructive
+// tool asks the user to type.
+const confirmField = "confirm"
+
+// confirmWord is typed to confirm a destructive call whose record has no
+// title to type instead.
+const confirmWord = "delete"
+
+// formSkips lists the arguments the form never offers for editing: which
+// instance the call targets was settled before the gate ran, and how the
+// call runs is the caller's business.
+var formSkips = []string{"instance", "dryRun", "wait"}
+
+// confirmForm is the elicitation form for one tool call. It shows the
+// instance and every argument, and offers the arguments of simple types for
+// editing, prefilled with what the model sent. A destructive call also asks
+// the user to type the title of the record it removes, and deleting files
+// from disk has to be ticked by the user rather than inherited from the
+// model. After an accepted prompt, edits holds the submitted values.
+type confirmForm struct {
+	service, instance string
+	access            Access
+	args              map[string]any
+	fields            map[string]*jsonschema.Schema
+	// title looks up what the user types to confirm a destructive call; nil
+	// means confirmWord.
+	title func(context.Context) (string, error)
+
+	schema *jsonschema.Schema
+	want   string
+	edits  map[string]any
+}
+
+// newConfirmForm builds the form for a call with arguments in, whose input
+// schema is schema.
+func newConfirmForm(service, instance string, access Access, schema *jsonschema.Schema, in any) (*confirmForm, error) {
+	var args map[string]any
+	if err := remarshal(in, &args); err != nil {
+		return nil, err
+	}
+	f := &confirmForm{service: service, instance: instance, access: access, args: args, fields: map[string]*jsonschema.Schema{}}
+	for name, prop := range schema.Properties {
+		if slices.Contains(formSkips, name) {
+			continue
+		}
+		if field := formField(name, prop); field != nil {
+			f.fields[name] = field
+		}
+	}
+	return f, nil
+}
+
+// formField converts one input property into a form field, or returns nil
+// for a type an elicitation form cannot hold, such as a list.
+func formField(name string, prop *jsonschema.Schema) *jsonschema.Schema {
+	typ := prop.Type
+	if typ == "" {
+		// Optional fields are pointers, typed as null or the value.
+		for _, t := range prop.Types {
+			if t != "null" {
+				typ = t
+			}
+		}
+	}
+	switch typ {
+	case "string", "integer", "number", "boolean":
+	default:
+		return nil
+	}
+	field := &jsonschema.Schema{Type: typ, Title: name, Description: prop.Description}
+	if typ == "string" {
+		field.Enum = prop.Enum
+	}
+	return field
+}
+
+// params builds the elicitation request, looking up the title to type for
+// a destructive call.
+func (f *confirmForm) params(ctx context.Context, prompt string) (*mcp.ElicitParams, error) {
+	shown := map[string]any{}
+	for name, v := range f.args {
+		if !slices.Contains(formSkips, name) {
+			shown[name] = v
+		}
+	}
+	arguments, err := json.Marshal(shown)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("%s\n\nInstance: %s %q\nArguments: %s", prompt, f.service, f.instance, arguments)
+
+	schema := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{}}
+	for name, field := range f.fields {
+		field := *field
+		if v, ok := f.args[name]; ok && v != nil {
+			field.Default, _ = json.Marshal(v)
+		}
+		schema.Properties[name] = &field
+	}
+
+	if f.access == AccessDestructive {
+		if field, ok := schema.Properties["deleteFiles"]; ok {
+			field.Title = "Also delete files from disk"
+			field.Description = "tick to delete the files too; left unticked, they stay on disk"
+			field.Default = json.RawMessage("false")
+		}
+		f.want = confirmWord
+		if f.title != nil {
+			if f.want, err = f.title(ctx); err != nil {
+				return nil, fmt.Errorf("looking up what to confirm: %w", err)
+			}
+		}
+		schema.Properties[confirmField] = &jsonschema.Schema{
+			Type:        "string",
+			Title:       fmt.Sprintf("Type %q to confirm", f.want),
+			Description: "this cannot be undone",
+		}
+		schema.Required = []string{confirmField}
+		message += fmt.Sprintf("\n\nType %q to confirm.", f.want)
+	}
+	f.schema = schema
+	return &mcp.ElicitParams{Mode: "form", Message: message, RequestedSchema: schema}, nil
+}
+
+// accept checks the submitted form against the one that was sent, fills in
+// the fields the client left out with their defaults, checks what the user
+// typed to confirm a destructive call and keeps the submitted values as edits.
+// A deleteFiles the client leaves out is therefore false, whatever the model
+// asked for.
+func (f *confirmForm) accept(content map[string]any) error {
+	rs, err := f.schema.Resolve(nil)
+	if err != nil {
+		return err
+	}
+	if content == nil {
+		content = map[string]any{}
+	}
+	if err := rs.ApplyDefaults(&content); err != nil {
+		return fmt.Errorf("confirmation form: %w", err)
+	}
+	if err := rs.Validate(content); err != nil {
+		return fmt.Errorf("confirmation form: %w", err)
+	}
+	if f.access == AccessDestructive {
+		typed, _ := content[confirmField].(string)
+		if !strings.EqualFold(strings.TrimSpace(typed), f.want) {
+			return fmt.Errorf("%w: typed %q to confirm, not %q", ErrDeclined, typed, f.want)
+		}
+	}
+	f.edits = map[string]any{}
+	for name, v := range content {
+		if _, ok := f.fields[name]; ok {
+			f.edits[name] = v
+		}
+	}
+	return nil
+}
+
+// applyEdits returns in with the values the user submitted in the form, or
+// in itself when the user was not asked.
+func applyEdits[In any](in In, f *confirmForm) (In, bool, error) {
+	if f == nil || len(f.edits) == 0 {
+		return in, false, nil
+	}
+	merged := make(map[string]any, len(f.args)+len(f.edits))
+	for name, v := range f.args {
+		merged[name] = v
+	}
+	for name, v := range f.edits {
+		merged[name] = v
+	}
+	var out In
+	if err := remarshal(merged, &out); err != nil {
+		return in, false, fmt.Errorf("applying the confirmation form: %w", err)
+	}
+	return out, true, nil
+}
+
+// remarshal converts v to out by way of JSON.
+func remarshal(v, out any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
@@ -2,8 +2,19 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func TestInterpretElicitAcceptApproves(t *testing.T) {
@@ -56,3 +67,242 @@ func TestSessionConfirmerWithoutSessionIsUnsupported(t *testing.T) {
 		t.Errorf("error = %v, want ErrConfirmUnsupported", err)
 	}
 }
+
+// bodyArr answers GETs with get and records the method, path and body of
+// everything else.
+func bodyArr(t *testing.T, get string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(get))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		sent = append(sent, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &sent
+}
+
+// confirmingClient connects to a confirm-mode server whose user answers
+// every prompt with answer, and records the prompts.
+func confirmingClient(t *testing.T, url string, answer func(*mcp.ElicitParams) *mcp.ElicitResult) (*mcp.ClientSession, *[]*mcp.ElicitParams) {
+	t.Helper()
+	var asked []*mcp.ElicitParams
+	cs := connectWith(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: url, APIKey: "k", Default: true}},
+	}, config.Permissions{Mode: config.ModeConfirm, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny}),
+		&mcp.ClientOptions{ElicitationHandler: func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			asked = append(asked, req.Params)
+			return answer(req.Params), nil
+		}})
+	return cs, &asked
+}
+
+// formProperty returns one property of a prompt's requested schema.
+func formProperty(t *testing.T, p *mcp.ElicitParams, name string) map[string]any {
+	t.Helper()
+	raw, _ := json.Marshal(p.RequestedSchema)
+	var schema struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema.Properties[name]
+}
+
+func TestConfirmFormShowsTheCallAndRunsTheEditedValues(t *testing.T) {
+	srv, sent := bodyArr(t, `[]`)
+	cs, asked := confirmingClient(t, srv.URL, func(*mcp.ElicitParams) *mcp.ElicitResult {
+		return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"qualityProfileId": 6}}
+	})
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "sonarr_add_series",
+		Arguments: map[string]any{
+			"tvdbId": 81189, "title": "Breaking Bad", "qualityProfileId": 4, "rootFolderPath": "/tv",
+		},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("CallTool: %v %s", err, contentText(res))
+	}
+	if len(*asked) != 1 {
+		t.Fatalf("prompted %d times, want once", len(*asked))
+	}
+	p := (*asked)[0]
+	if p.Mode != "form" || !strings.Contains(p.Message, `sonarr "main"`) || !strings.Contains(p.Message, `"title":"Breaking Bad"`) {
+		t.Errorf("prompt = %s %q, want a form naming the instance and arguments", p.Mode, p.Message)
+	}
+	if def := formProperty(t, p, "qualityProfileId")["default"]; def != 4.0 {
+		t.Errorf("qualityProfileId default = %v, want the model's 4", def)
+	}
+	if formProperty(t, p, "instance") != nil {
+		t.Error("the form offers to change the instance")
+	}
+	if len(*sent) != 1 || !strings.Contains((*sent)[0], `"qualityProfileId":6`) || !strings.Contains((*sent)[0], `"tvdbId":81189`) {
+		t.Errorf("sent %v, want the add with the edited quality profile", *sent)
+	}
+}
+
+func TestDestructiveConfirmationRequiresTheTitle(t *testing.T) {
+	for _, tc := range []struct {
+		name, typed string
+		want        bool
+	}{
+		{"matching", " breaking bad ", true},
+		{"wrong", "yes", false},
+	} {
+		srv, sent := bodyArr(t, `{"id":3,"title":"Breaking Bad"}`)
+		cs, asked := confirmingClient(t, srv.URL, func(*mcp.ElicitParams) *mcp.ElicitResult {
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"confirm": tc.typed}}
+		})
+
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+			Name:      "sonarr_delete_series",
+			Arguments: map[string]any{"id": 3},
+		})
+		if err != nil {
+			t.Fatalf("%s: CallTool: %v", tc.name, err)
+		}
+		if ran := !res.IsError; ran != tc.want {
+			t.Errorf("%s: ran = %v, want %v (%s)", tc.name, ran, tc.want, contentText(res))
+		}
+		if len(*asked) != 1 {
+			t.Fatalf("%s: prompted %d times, want once", tc.name, len(*asked))
+		}
+		if title := formProperty(t, (*asked)[0], "confirm")["title"]; title != `Type "Breaking Bad" to confirm` {
+			t.Errorf("%s: confirm field title = %v", tc.name, title)
+		}
+		if got := len(*sent); got != map[bool]int{true: 1, false: 0}[tc.want] {
+			t.Errorf("%s: sent %v", tc.name, *sent)
+		}
+	}
+}
+
+// The model asking to delete files is not enough: the user must tick it.
+func TestDeletingFilesMustBeTickedByTheUser(t *testing.T) {
+	for _, tick := range []bool{false, true} {
+		srv, sent := bodyArr(t, `{"id":3,"title":"Breaking Bad"}`)
+		cs, asked := confirmingClient(t, srv.URL, func(*mcp.ElicitParams) *mcp.ElicitResult {
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"confirm": "Breaking Bad", "deleteFiles": tick}}
+		})
+
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+			Name:      "sonarr_delete_series",
+			Arguments: map[string]any{"id": 3, "deleteFiles": true},
+		})
+		if err != nil || res.IsError {
+			t.Fatalf("CallTool: %v %s", err, contentText(res))
+		}
+		if len(*asked) != 1 {
+			t.Fatalf("prompted %d times, want once", len(*asked))
+		}
+		if def := formProperty(t, (*asked)[0], "deleteFiles")["default"]; def != false {
+			t.Errorf("deleteFiles default = %v, want unticked", def)
+		}
+		want := "DELETE /api/v3/series/3?deleteFiles=" + strconv.FormatBool(tick) + " "
+		if len(*sent) != 1 || (*sent)[0] != want {
+			t.Errorf("ticked %v: sent %q, want %q", tick, *sent, want)
+		}
+	}
+}
+
+// Editing the command in the form would dodge a tool rule judged on the one
+// the user was asked about.
+func TestConfirmFormCannotChangeTheRuledArgument(t *testing.T) {
+	srv, sent := bodyArr(t, `{}`)
+	cs, _ := confirmingClient(t, srv.URL, func(*mcp.ElicitParams) *mcp.ElicitResult {
+		return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"name": "Backup"}}
+	})
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_run_command",
+		Arguments: map[string]any{"name": "RssSync"},
+	})
+	if err == nil && !res.IsError {
+		t.Error("the call ran with the command changed in the form")
+	}
+	if len(*sent) != 0 {
+		t.Errorf("sent %v, want nothing", *sent)
+	}
+}
+
+// The title typed confirms one record; editing the id must not delete another.
+func TestConfirmFormCannotChangeTheRecordToDelete(t *testing.T) {
+	srv, sent := bodyArr(t, `{"id":3,"title":"Breaking Bad"}`)
+	cs, _ := confirmingClient(t, srv.URL, func(*mcp.ElicitParams) *mcp.ElicitResult {
+		return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"confirm": "Breaking Bad", "id": 4}}
+	})
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_delete_series",
+		Arguments: map[string]any{"id": 3},
+	})
+	if err == nil && !res.IsError {
+		t.Error("the delete ran against the id edited in the form")
+	}
+	if len(*sent) != 0 {
+		t.Errorf("sent %v, want nothing", *sent)
+	}
+}
+
+// An answer only counts for the call its prompt was made for.
+func TestConfirmationStateIsBoundToTheCall(t *testing.T) {
+	key := newConfirmKey()
+	asked := sessionConfirmer{key: key, binding: confirmBinding("sonarr_delete_series", "sonarr", "main",
+		json.RawMessage(`{"id":3,"deleteFiles":false}`))}
+	state := asked.sign(time.Now())
+
+	for name, tc := range map[string]struct {
+		args  string
+		state string
+		ok    bool
+	}{
+		"same call":          {`{"deleteFiles":false,"id":3}`, state, true},
+		"another record":     {`{"id":4,"deleteFiles":false}`, state, false},
+		"no state":           {`{"id":3,"deleteFiles":false}`, "", false},
+		"expired":            {`{"id":3,"deleteFiles":false}`, asked.sign(time.Now().Add(-confirmTTL - time.Minute)), false},
+		"tampered timestamp": {`{"id":3,"deleteFiles":false}`, "1" + state, false},
+	} {
+		c := sessionConfirmer{key: key, state: tc.state, binding: confirmBinding("sonarr_delete_series", "sonarr", "main",
+			json.RawMessage(tc.args))}
+		if _, err := c.verify(); (err == nil) != tc.ok {
+			t.Errorf("%s: verify err = %v, want ok = %v", name, err, tc.ok)
+		}
+	}
+	if _, err := (sessionConfirmer{key: newConfirmKey(), state: state, binding: asked.binding}).verify(); err == nil {
+		t.Error("state signed by another process was accepted")
+	}
+}
+
+// A model cannot skip the prompt by answering it in advance.
+func TestAnswerWithoutAPromptIsRefused(t *testing.T) {
+	srv, sent := bodyArr(t, `{"id":3,"title":"Breaking Bad"}`)
+	cs, asked := confirmingClient(t, srv.URL, func(*mcp.ElicitParams) *mcp.ElicitResult {
+		return &mcp.ElicitResult{Action: "decline"}
+	})
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_delete_series",
+		Arguments: map[string]any{"id": 3},
+		InputResponses: mcp.InputResponseMap{confirmRequestID: &mcp.ElicitResult{
+			Action: "accept", Content: map[string]any{"confirm": "Breaking Bad"},
+		}},
+	})
+	if err == nil && !res.IsError {
+		t.Error("a call answering a prompt it was never given ran")
+	}
+	if len(*sent) != 0 {
+		t.Errorf("sent %v, want nothing", *sent)
+	}
+	if len(*asked) != 0 {
+		t.Errorf("the user was prompted %d times for a refused answer", len(*asked))
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// DryRun is what a mutating tool returns in place of its usual output when
+// the call, or the whole server, is a dry run: the requests it would have
+// sent and, for the bulk editors, the records they would have changed.
+type DryRun struct {
+	DryRun   bool                 `json:"dryRun" jsonschema:"always true: nothing was sent to the service"`
+	Tool     string               `json:"tool"`
+	Instance string               `json:"instance"`
+	Requests []arr.PlannedRequest `json:"requests" jsonschema:"the requests the tool would have sent, in order"`
+	Changes  []arr.RecordChange   `json:"changes,omitempty" jsonschema:"records a bulk edit would change, with the fields that differ"`
+	Note     string               `json:"note"`
+}
+
+// dryRunNote explains how far the planned requests can be trusted.
+const dryRunNote = "Nothing was sent. Each write was treated as succeeding with an empty response, " +
+	"so values a later request took from an earlier response may be placeholders."
+
+func newDryRun(tool, instance string, plan *arr.Plan) *DryRun {
+	return &DryRun{
+		DryRun:   true,
+		Tool:     tool,
+		Instance: instance,
+		Requests: plan.Requests(),
+		Changes:  plan.Changes(),
+		Note:     dryRunNote,
+	}
+}
+
+// dryRunRequested reports whether the raw tool arguments set dryRun. The
+// input schema has already checked that it is a boolean.
+func dryRunRequested(args json.RawMessage) bool {
+	var a struct {
+		DryRun bool `json:"dryRun"`
+	}
+	_ = json.Unmarshal(args, &a)
+	return a.DryRun
+}
+
+// ignoreDryRun hides ErrDryRun from a trace: a dry run is not a failure.
+func ignoreDryRun(err error) error {
+	if errors.Is(err, arr.ErrDryRun) {
+		return nil
+	}
+	return err
+}
+
+// dryRunOutputSchema describes the output of a mutating tool: either Out or
+// a DryRun.
+func dryRunOutputSchema[Out any]() (*jsonschema.Schema, error) {
+	out, err := jsonschema.For[Out](nil)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := jsonschema.For[DryRun](nil)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonschema.Schema{Type: "object", AnyOf: []*jsonschema.Schema{out, plan}}, nil
+}
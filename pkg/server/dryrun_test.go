@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// dryRunResult decodes the plan a dry-run call returned.
+func dryRunResult(t *testing.T, res *mcp.CallToolResult) DryRun {
+	t.Helper()
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+	raw, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan DryRun
+	if err := json.Unmarshal(raw, &plan); err != nil || !plan.DryRun {
+		t.Fatalf("structured content = %s, want a dry run (%v)", raw, err)
+	}
+	return plan
+}
+
+func TestDryRunArgumentReportsTheRequestWithoutSendingIt(t *testing.T) {
+	srv, paths := recordingArr(t, `{}`)
+	cs := connect(t, mediaCfg(srv.URL))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_delete_series",
+		Arguments: map[string]any{"id": 3, "deleteFiles": true, "dryRun": true},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	plan := dryRunResult(t, res)
+	want := arr.PlannedRequest{Method: "DELETE", Path: "/api/v3/series/3?deleteFiles=true"}
+	if len(plan.Requests) != 1 || plan.Requests[0].Method != want.Method || plan.Requests[0].Path != want.Path {
+		t.Errorf("requests = %+v, want %+v", plan.Requests, want)
+	}
+	if plan.Tool != "sonarr_delete_series" || plan.Instance != "main" {
+		t.Errorf("plan names %s on %q, want sonarr_delete_series on main", plan.Tool, plan.Instance)
+	}
+	if len(*paths) != 0 {
+		t.Errorf("upstream calls = %v, want none", *paths)
+	}
+}
+
+func TestServerWideDryRunReportsWhichRecordsAnEditWouldChange(t *testing.T) {
+	srv, paths := recordingArr(t, `[{"id":5,"title":"Severance","monitored":true},{"id":6,"title":"Andor","monitored":false}]`)
+	cfg := mediaCfg(srv.URL)
+	cfg.Server.DryRun = true
+	cs := connect(t, cfg)
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_edit_series",
+		Arguments: map[string]any{"seriesIds": []any{5, 6}, "monitored": false},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	plan := dryRunResult(t, res)
+	if len(plan.Requests) != 1 || plan.Requests[0].Method != "PUT" || plan.Requests[0].Path != "/api/v3/series/editor" {
+		t.Errorf("requests = %+v, want the PUT to the series editor", plan.Requests)
+	}
+	body, _ := plan.Requests[0].Body.(map[string]any)
+	if body["monitored"] != false {
+		t.Errorf("planned body = %v, want monitored false", plan.Requests[0].Body)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].ID != 5 || plan.Changes[0].Title != "Severance" {
+		t.Errorf("changes = %+v, want only Severance", plan.Changes)
+	}
+	for _, p := range *paths {
+		if p != "GET /api/v3/series" {
+			t.Errorf("upstream call %s, want only reads", p)
+		}
+	}
+}
+
+// A dry run shows what an allowed call would do; it is no way around the gate.
+func TestDryRunIsStillAuthorized(t *testing.T) {
+	srv, paths := recordingArr(t, `{}`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, config.Permissions{Mode: config.ModeConfirm, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny}))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_delete_tag",
+		Arguments: map[string]any{"id": 1, "dryRun": true},
+	})
+	if err == nil && !res.IsError {
+		t.Error("an unconfirmed dry run was allowed")
+	}
+	if len(*paths) != 0 {
+		t.Errorf("upstream calls = %v, want none", *paths)
+	}
+}
+
+func TestOnlyMutatingToolsTakeDryRun(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, mediaCfg(srv.URL))
+
+	res, err := cs.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	for _, tool := range res.Tools {
+		raw, _ := json.Marshal(tool.InputSchema)
+		var schema struct {
+			Properties map[string]any `json:"properties"`
+		}
+		_ = json.Unmarshal(raw, &schema)
+		_, takes := schema.Properties["dryRun"]
+		if readOnly := tool.Annotations != nil && tool.Annotations.ReadOnlyHint; takes == readOnly {
+			t.Errorf("%s: dryRun property = %v, read-only = %v", tool.Name, takes, readOnly)
+		}
+	}
+}
+
+// Every file of a bulk delete is planned, not only the first.
+func TestDryRunPlansEveryFileOfABulkDelete(t *testing.T) {
+	srv, paths := recordingArr(t, `{}`)
+	cs := connect(t, mediaCfg(srv.URL))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_delete_episode_files",
+		Arguments: map[string]any{"fileIds": []any{4, 5, 6}, "dryRun": true},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if plan := dryRunResult(t, res); len(plan.Requests) != 3 {
+		t.Errorf("requests = %+v, want all three deletes", plan.Requests)
+	}
+	if len(*paths) != 0 {
+		t.Errorf("upstream calls = %v, want none", *paths)
+	}
+}
+
+// A dry run that ends up writing nothing is still marked as one.
+func TestDryRunWithoutWritesIsStillMarked(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	s := New(mediaCfg(srv.URL), logger.New("error", "test"))
+	s.pass = newCatalog()
+	register(s, "sonarr", arr.SonarrSpec, toolMeta{name: "sonarr_noop", description: "writes nothing", access: AccessWrite},
+		func(context.Context, *arr.Client, EmptyArgs) (Deleted, error) { return Deleted{ID: 1}, nil })
+	s.pass = nil
+	cs := connectServer(t, s, nil)
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_noop",
+		Arguments: map[string]any{"dryRun": true},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if plan := dryRunResult(t, res); len(plan.Requests) != 0 {
+		t.Errorf("requests = %+v, want none", plan.Requests)
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func scrape(t *testing.T, base string) string {
+	t.Helper()
+	resp, err := http.Get(base + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("/metrics status = %d", resp.StatusCode)
+	}
+	return string(body)
+}
+
+func TestMetricsCoverToolCallsUpstreamAndSessions(t *testing.T) {
+	srv, _ := fakeArr(t, `{"id":1,"label":"metrics"}`)
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "metricsbox", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull)
+	base := serveHTTP(t, cfg)
+	cs := connectHTTP(t, base, "")
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_create_tag",
+		Arguments: map[string]any{"label": "metrics"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("CallTool: %v", err)
+	}
+
+	body := scrape(t, base)
+	for _, want := range []string{
+		`arrmcp_tool_call_duration_seconds_count{tool="sonarr_create_tag",instance="metricsbox",outcome="ok"} 1`,
+		`arrmcp_upstream_request_duration_seconds_count{service="sonarr",method="POST",code="200"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %q:\n%s", want, body)
+		}
+	}
+	// The client may hold more than one session while negotiating a protocol
+	// version, so only require that the open one is counted.
+	if !strings.Contains(body, "\narrmcp_active_sessions ") || strings.Contains(body, "\narrmcp_active_sessions 0\n") {
+		t.Errorf("/metrics does not count the open session:\n%s", body)
+	}
+}
+
+func TestMetricsCountRefusedCalls(t *testing.T) {
+	srv, _ := fakeArr(t, `{}`)
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "refusedbox", URL: srv.URL, APIKey: "k", Default: true}},
+	}, config.Permissions{Mode: config.ModeConfirm, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny})
+	base := serveHTTP(t, cfg)
+	cs := connectHTTP(t, base, "")
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_delete_tag",
+		Arguments: map[string]any{"id": 1},
+	})
+	if err != nil || !res.IsError {
+		t.Fatalf("expected the call to be refused, got err=%v", err)
+	}
+
+	body := scrape(t, base)
+	for _, want := range []string{
+		`arrmcp_tool_call_duration_seconds_count{tool="sonarr_delete_tag",instance="refusedbox",outcome="denied"} 1`,
+		`arrmcp_permission_decisions_total{tool="sonarr_delete_tag",outcome="denied"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %q:\n%s", want, body)
+		}
+	}
+}
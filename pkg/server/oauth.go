@@ -0,0 +1,361 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+// protectedResourcePath is where the RFC 9728 metadata document is served.
+const protectedResourcePath = "/.well-known/oauth-protected-resource"
+
+// Clock skew tolerated on exp and nbf, and the shortest gap between JWKS
+// refetches triggered by an unknown key id.
+var (
+	jwtLeeway        = time.Minute
+	jwksRefreshFloor = time.Minute
+)
+
+// minRSABits is the smallest RSA modulus accepted from a key set.
+const minRSABits = 2048
+
+// jwtVerifier validates JWT access tokens against one issuer's key set.
+type jwtVerifier struct {
+	cfg  config.OAuthConfig
+	http *http.Client
+
+	// mu guards the fields below. It is never held while the key set is
+	// fetched, so a slow issuer only delays the calls that need new keys.
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	fetched  time.Time
+	inflight *jwksFetch
+}
+
+// jwksFetch is one fetch of the key set, shared by every caller that asks
+// for a refresh while it runs.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+func newJWTVerifier(cfg config.OAuthConfig) *jwtVerifier {
+	return &jwtVerifier{cfg: cfg, http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// refresh reloads the key set from its file or URL.
+func (v *jwtVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	seen := v.fetched
+	v.mu.Unlock()
+	return v.refreshSince(ctx, seen)
+}
+
+// refreshSince reloads the key set unless it has been reloaded since seen,
+// joining a fetch already under way instead of starting another.
+func (v *jwtVerifier) refreshSince(ctx context.Context, seen time.Time) error {
+	v.mu.Lock()
+	if !v.fetched.Equal(seen) {
+		v.mu.Unlock()
+		return nil
+	}
+	f := v.inflight
+	if f != nil {
+		v.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	f = &jwksFetch{done: make(chan struct{})}
+	v.inflight = f
+	v.mu.Unlock()
+
+	// Others may be waiting on this fetch, so it outlives the caller that
+	// started it; the client timeout still bounds it.
+	keys, err := v.loadKeys(context.WithoutCancel(ctx))
+
+	v.mu.Lock()
+	if err == nil {
+		v.keys = keys
+		v.fetched = time.Now()
+	}
+	f.err = err
+	v.inflight = nil
+	v.mu.Unlock()
+	close(f.done)
+	return err
+}
+
+func (v *jwtVerifier) loadKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	raw, err := v.readJWKS(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading JWKS %s: %w", v.cfg.JWKS, err)
+	}
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing JWKS %s: %w", v.cfg.JWKS, err)
+	}
+	return keys, nil
+}
+
+func (v *jwtVerifier) readJWKS(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(v.cfg.JWKS, "http://") && !strings.HasPrefix(v.cfg.JWKS, "https://") {
+		// The path comes from the operator's own config file.
+		return os.ReadFile(v.cfg.JWKS) // #nosec G304
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKS, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// key returns the verification key for kid, refetching the key set once when
+// the id is unknown so a rotated issuer key is picked up without a restart.
+func (v *jwtVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	// A key set is replaced whole, never edited, so it can be read unlocked.
+	v.mu.Lock()
+	keys, fetched := v.keys, v.fetched
+	v.mu.Unlock()
+
+	if keys == nil || (lookupKey(keys, kid) == nil && time.Since(fetched) > jwksRefreshFloor) {
+		if err := v.refreshSince(ctx, fetched); err != nil {
+			return nil, err
+		}
+		v.mu.Lock()
+		keys = v.keys
+		v.mu.Unlock()
+	}
+	if k := lookupKey(keys, kid); k != nil {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: no key %q in the issuer's key set", auth.ErrInvalidToken, kid)
+}
+
+// lookupKey finds kid, or the only key when the token names none.
+func lookupKey(keys map[string]crypto.PublicKey, kid string) crypto.PublicKey {
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k
+		}
+	}
+	return keys[kid]
+}
+
+// jwtClaims holds the registered claims this server checks.
+type jwtClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	Expiry    int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	Scope     string   `json:"scope"`
+	// Scp is the array form some issuers use instead of scope.
+	Scp []string `json:"scp"`
+}
+
+// audience accepts the aud claim as either a string or an array.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// verify checks the token's signature and claims and returns what it grants.
+func (v *jwtVerifier) verify(ctx context.Context, token string) (*auth.TokenInfo, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWT", auth.ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", auth.ErrInvalidToken, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", auth.ErrInvalidToken, err)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Alg, key, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, err)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", auth.ErrInvalidToken, err)
+	}
+	now := time.Now()
+	switch {
+	case claims.Issuer != v.cfg.Issuer:
+		return nil, fmt.Errorf("%w: issuer %q is not trusted", auth.ErrInvalidToken, claims.Issuer)
+	case !slices.Contains(claims.Audience, v.cfg.Audience):
+		return nil, fmt.Errorf("%w: token is not for audience %s", auth.ErrInvalidToken, v.cfg.Audience)
+	case claims.Expiry == 0:
+		return nil, fmt.Errorf("%w: token has no expiry", auth.ErrInvalidToken)
+	case now.After(time.Unix(claims.Expiry, 0).Add(jwtLeeway)):
+		return nil, fmt.Errorf("%w: token expired", auth.ErrInvalidToken)
+	case claims.NotBefore != 0 && now.Add(jwtLeeway).Before(time.Unix(claims.NotBefore, 0)):
+		return nil, fmt.Errorf("%w: token not yet valid", auth.ErrInvalidToken)
+	}
+
+	// Scopes must be non-nil even when empty, so a token without arr scopes
+	// is limited to nothing rather than treated as unrestricted.
+	scopes := append([]string{}, strings.Fields(claims.Scope)...)
+	return &auth.TokenInfo{
+		Scopes:     append(scopes, claims.Scp...),
+		Expiration: time.Unix(claims.Expiry, 0),
+		UserID:     "oauth:" + claims.Subject,
+		Extra:      map[string]any{oauthTokenKey: true},
+	}, nil
+}
+
+func decodeSegment(seg string, out any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// verifySignature supports the two algorithms identity providers issue by
+// default. "none" and HMAC are refused: neither proves the issuer signed it.
+func verifySignature(alg string, key crypto.PublicKey, digest, sig []byte) error {
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("RS256 token signed with a non-RSA key")
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, sig)
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return errors.New("ES256 token signed with a non-P-256 key")
+		}
+		if len(sig) != 64 {
+			return errors.New("malformed ES256 signature")
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported signing algorithm %q", alg)
+}
+
+// parseJWKS reads the RSA and P-256 signing keys from a JWK set, skipping key
+// types this server cannot use rather than rejecting the whole set. RSA keys
+// shorter than minRSABits are skipped too: a signature from one proves little.
+func parseJWKS(raw []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch {
+		case k.Kty == "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: n: %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: e: %w", k.Kid, err)
+			}
+			pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			if pub.N.BitLen() < minRSABits {
+				continue
+			}
+			keys[k.Kid] = pub
+		case k.Kty == "EC" && k.Crv == "P-256":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: x: %w", k.Kid, err)
+			}
+			y, err := base64.RawURLEncoding.DecodeString(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: y: %w", k.Kid, err)
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable RSA (%d bits or more) or P-256 signing keys", minRSABits)
+	}
+	return keys, nil
+}
+
+// resourceMetadataURL is the absolute URL of the metadata document for the
+// configured audience, advertised in WWW-Authenticate challenges.
+func resourceMetadataURL(o *config.OAuthConfig) string {
+	u, err := url.Parse(o.Audience)
+	if err != nil {
+		return ""
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: protectedResourcePath}).String()
+}
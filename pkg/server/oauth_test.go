@@ -0,0 +1,319 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	testIssuer   = "https://id.example.test/realm"
+	testAudience = "https://arr.example.test/mcp"
+)
+
+// testIssuerKeys is a locally generated stand-in for an identity provider.
+type testIssuerKeys struct {
+	ec  *ecdsa.PrivateKey
+	rsa *rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuerKeys {
+	t.Helper()
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating EC key: %v", err)
+	}
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	return &testIssuerKeys{ec: ec, rsa: rk}
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// jwks renders the public half of both keys as a JWK set.
+func (k *testIssuerKeys) jwks() []byte {
+	set := map[string]any{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "use": "sig",
+			"x": b64(k.ec.X.FillBytes(make([]byte, 32))), "y": b64(k.ec.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "rsa", "use": "sig",
+			"n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+	}}
+	raw, _ := json.Marshal(set)
+	return raw
+}
+
+// jwksFile writes the key set to a temporary file and returns its path.
+func (k *testIssuerKeys) jwksFile(t *testing.T) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(p, k.jwks(), 0o600); err != nil {
+		t.Fatalf("writing JWKS: %v", err)
+	}
+	return p
+}
+
+// sign issues a token with the given algorithm ("ES256" or "RS256").
+func (k *testIssuerKeys) sign(t *testing.T, alg string, claims map[string]any) string {
+	t.Helper()
+	kid := map[string]string{"ES256": "ec", "RS256": "rsa"}[alg]
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	body, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(body)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch alg {
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatalf("signing: %v", err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "RS256":
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("signing: %v", err)
+		}
+	}
+	return input + "." + b64(sig)
+}
+
+// claims returns valid claims granting scope, with overrides applied.
+func claims(scope string, overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": scope,
+	}
+	for k, v := range overrides {
+		c[k] = v
+	}
+	return c
+}
+
+func TestJWTVerifierChecksSignatureAndClaims(t *testing.T) {
+	keys := newTestIssuer(t)
+	other := newTestIssuer(t)
+	v := newJWTVerifier(config.OAuthConfig{Issuer: testIssuer, Audience: testAudience, JWKS: keys.jwksFile(t)})
+
+	info, err := v.verify(context.Background(), keys.sign(t, "ES256", claims("arr:read arr:write", nil)))
+	if err != nil {
+		t.Fatalf("valid ES256 token rejected: %v", err)
+	}
+	if strings.Join(info.Scopes, " ") != "arr:read arr:write" {
+		t.Errorf("scopes = %v, want arr:read arr:write", info.Scopes)
+	}
+	if _, err := v.verify(context.Background(), keys.sign(t, "RS256",
+		claims("", map[string]any{"aud": []string{"other", testAudience}, "scp": []string{"arr:read"}}))); err != nil {
+		t.Errorf("valid RS256 token with an audience array rejected: %v", err)
+	}
+
+	bad := map[string]string{
+		"foreign key":    other.sign(t, "ES256", claims("arr:read", nil)),
+		"wrong issuer":   keys.sign(t, "ES256", claims("arr:read", map[string]any{"iss": "https://evil.test"})),
+		"wrong audience": keys.sign(t, "ES256", claims("arr:read", map[string]any{"aud": "https://other.test/mcp"})),
+		"expired":        keys.sign(t, "ES256", claims("arr:read", map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"no expiry":      keys.sign(t, "ES256", claims("arr:read", map[string]any{"exp": 0})),
+		"not yet valid":  keys.sign(t, "ES256", claims("arr:read", map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"unsigned":       b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{}`)) + ".",
+		"not a JWT":      "static-looking-token",
+	}
+	for name, token := range bad {
+		if _, err := v.verify(context.Background(), token); !errors.Is(err, auth.ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestJWTVerifierLoadsKeySetFromURL(t *testing.T) {
+	keys := newTestIssuer(t)
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches++
+		_, _ = w.Write(keys.jwks())
+	}))
+	t.Cleanup(srv.Close)
+
+	v := newJWTVerifier(config.OAuthConfig{Issuer: testIssuer, Audience: testAudience, JWKS: srv.URL})
+	for range 2 {
+		if _, err := v.verify(context.Background(), keys.sign(t, "RS256", claims("arr:read", nil))); err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("JWKS fetched %d times, want once and then cached", fetches)
+	}
+}
+
+// A refetch for a rotated key runs once however many calls ask for it, and
+// calls with keys already known do not wait on it.
+func TestJWKSRefetchDoesNotBlockKnownKeys(t *testing.T) {
+	keys := newTestIssuer(t)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		_, _ = w.Write(keys.jwks())
+	}))
+	t.Cleanup(srv.Close)
+
+	v := newJWTVerifier(config.OAuthConfig{Issuer: testIssuer, Audience: testAudience, JWKS: srv.URL})
+	known := keys.sign(t, "ES256", claims("arr:read", nil))
+	if _, err := v.verify(context.Background(), known); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	v.mu.Lock()
+	v.fetched = time.Now().Add(-2 * jwksRefreshFloor)
+	v.mu.Unlock()
+
+	rotated := b64([]byte(`{"alg":"ES256","kid":"rotated"}`)) + "." + b64([]byte(`{}`)) + "." + b64(make([]byte, 64))
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			if _, err := v.verify(context.Background(), rotated); !errors.Is(err, auth.ErrInvalidToken) {
+				t.Errorf("token for an unknown key: err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+	for deadline := time.Now().Add(5 * time.Second); fetches.Load() < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("unknown key id did not trigger a refetch")
+		}
+	}
+
+	verified := make(chan error, 1)
+	go func() {
+		_, err := v.verify(context.Background(), known)
+		verified <- err
+	}()
+	select {
+	case err := <-verified:
+		if err != nil {
+			t.Errorf("known key rejected during a refetch: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("a known key waited on the refetch")
+	}
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want one refetch shared by every caller", n)
+	}
+}
+
+func TestJWKSSkipsShortRSAKeys(t *testing.T) {
+	short := b64(new(big.Int).Lsh(big.NewInt(1), 1023).Bytes())
+	keys, err := parseJWKS([]byte(`{"keys":[
+		{"kty":"RSA","kid":"short","n":"` + short + `","e":"AQAB"},
+		{"kty":"EC","kid":"ec","crv":"P-256","x":"AA","y":"AA"}]}`))
+	if err != nil {
+		t.Fatalf("parseJWKS: %v", err)
+	}
+	if _, ok := keys["short"]; ok || keys["ec"] == nil {
+		t.Errorf("keys = %v, want only the P-256 key", keys)
+	}
+	if _, err := parseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"short","n":"` + short + `","e":"AQAB"}]}`)); err == nil {
+		t.Error("a key set holding only a 1024-bit RSA key was accepted")
+	}
+}
+
+func oauthCfg(t *testing.T, upstream string, keys *testIssuerKeys) *config.Config {
+	t.Helper()
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: upstream, APIKey: "k", Default: true}},
+	}, permsFull)
+	cfg.Server.Auth.OAuth = &config.OAuthConfig{Issuer: testIssuer, Audience: testAudience, JWKS: keys.jwksFile(t)}
+	return cfg
+}
+
+func TestOAuthServesProtectedResourceMetadata(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	base := serveHTTP(t, oauthCfg(t, srv.URL, newTestIssuer(t)))
+
+	resp, err := http.Get(base + "/.well-known/oauth-protected-resource")
+	if err != nil {
+		t.Fatalf("GET metadata: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var meta struct {
+		Resource             string   `json:"resource"`
+		AuthorizationServers []string `json:"authorization_servers"`
+		ScopesSupported      []string `json:"scopes_supported"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		t.Fatalf("decoding metadata: %v", err)
+	}
+	if meta.Resource != testAudience || len(meta.AuthorizationServers) != 1 || meta.AuthorizationServers[0] != testIssuer {
+		t.Errorf("metadata = %+v, want resource %s issued by %s", meta, testAudience, testIssuer)
+	}
+	if !has(meta.ScopesSupported, ScopeDestructive) {
+		t.Errorf("scopes_supported = %v, want the arr scopes", meta.ScopesSupported)
+	}
+
+	unauth, err := http.Post(base+"/mcp", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("POST /mcp: %v", err)
+	}
+	_ = unauth.Body.Close()
+	if unauth.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", unauth.StatusCode)
+	}
+	if got := unauth.Header.Get("WWW-Authenticate"); !strings.Contains(got, "https://arr.example.test/.well-known/oauth-protected-resource") {
+		t.Errorf("WWW-Authenticate = %q, want the metadata URL", got)
+	}
+}
+
+func TestOAuthScopesGateToolCalls(t *testing.T) {
+	srv, hits := fakeArr(t, `{"id":1,"label":"4k"}`)
+	keys := newTestIssuer(t)
+	base := serveHTTP(t, oauthCfg(t, srv.URL, keys))
+	create := &mcp.CallToolParams{Name: "sonarr_create_tag", Arguments: map[string]any{"label": "4k"}}
+
+	reader := connectHTTP(t, base, keys.sign(t, "ES256", claims(ScopeRead, nil)))
+	res, err := reader.CallTool(context.Background(), create)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if !res.IsError || !strings.Contains(contentText(res), ScopeWrite) {
+		t.Errorf("read-scoped write result = %q, want a refusal naming %s", contentText(res), ScopeWrite)
+	}
+	if *hits != 0 {
+		t.Errorf("upstream contacted %d times for a refused write", *hits)
+	}
+
+	writer := connectHTTP(t, base, keys.sign(t, "ES256", claims(ScopeWrite, nil)))
+	res, err = writer.CallTool(context.Background(), create)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Errorf("write-scoped token was refused: %s", contentText(res))
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/metrics"
+	"github.com/GauranshMathur/ARR_MCP/pkg/tracing"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -34,6 +37,37 @@ func (a Access) String() string {
 	return "unknown"
 }
 
+// OAuth scopes, one per access tier. Tiers are cumulative: a token granted
+// arr:write may also read, and arr:destructive may do anything.
+const (
+	ScopeRead        = "arr:read"
+	ScopeWrite       = "arr:write"
+	ScopeDestructive = "arr:destructive"
+)
+
+// Scope names the OAuth scope that grants this tier.
+func (a Access) Scope() string {
+	switch a {
+	case AccessRead:
+		return ScopeRead
+	case AccessWrite:
+		return ScopeWrite
+	}
+	return ScopeDestructive
+}
+
+// grantedBy reports whether any of scopes covers this tier.
+func (a Access) grantedBy(scopes []string) bool {
+	for _, s := range scopes {
+		for tier := a; tier <= AccessDestructive; tier++ {
+			if s == tier.Scope() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Annotations returns the MCP hints matching this access tier so clients can
 // render their own warnings independently of our gating.
 func (a Access) Annotations() *mcp.ToolAnnotations {
@@ -51,12 +85,20 @@ var (
 	ErrDeclined = errors.New("the user declined this action")
 	// ErrConfirmUnsupported means the client cannot prompt the user at all.
 	ErrConfirmUnsupported = errors.New("client does not support elicitation")
+	// ErrConfirmPending means the prompt has gone to the client and the call
+	// is decided when the client returns with the user's answer.
+	ErrConfirmPending = errors.New("waiting for the user to confirm")
 	// ErrReadOnly means the server is configured to refuse all mutations.
 	ErrReadOnly = errors.New("server is running in readonly mode")
+	// ErrInsufficientScope means the caller's OAuth token does not grant the tier.
+	ErrInsufficientScope = errors.New("access token lacks the required scope")
+	// ErrToolNotPermitted means allowTools or denyTools excludes the tool.
+	ErrToolNotPermitted = errors.New("tool is not permitted by the permission policy")
 )
 
 // Confirmer asks the user to approve an action. Implementations return
-// ErrConfirmUnsupported when the connected client cannot prompt.
+// ErrConfirmUnsupported when the connected client cannot prompt, and
+// ErrConfirmPending when the answer arrives with a later call.
 type Confirmer interface {
 	Confirm(ctx context.Context, prompt string) (bool, error)
 }
@@ -64,16 +106,33 @@ type Confirmer interface {
 // Gate applies the configured permission policy to tool calls.
 type Gate struct {
 	Perms config.Permissions
+	// Scopes, when non-nil, are the OAuth scopes granted to the caller.
+	// Tiers they do not cover are refused whatever the policy allows.
+	Scopes []string
 }
 
 // Registers reports whether a tool of this access tier should be exposed at all.
-// Filtering at registration keeps readonly deployments from advertising tools
-// they would only refuse later.
-func (g Gate) Registers(a Access) bool {
-	if g.Perms.Mode == config.ModeReadOnly {
-		return a == AccessRead
+// Filtering at registration keeps readonly deployments, and tools the tool
+// rules exclude, from advertising tools they would only refuse later. An
+// empty tool, as for resources and prompts, is judged by its tier alone.
+func (g Gate) Registers(tool string, a Access) bool {
+	p, ok := g.Perms.ForTool(tool)
+	if !ok {
+		return false
+	}
+	if p.Mode != config.ModeReadOnly || a == AccessRead {
+		return true
 	}
-	return true
+	if tool == "" {
+		return false
+	}
+	// A readonly tool is still advertised if one of its arguments may run.
+	for _, m := range g.Perms.ArgumentModes(tool) {
+		if m != config.ModeReadOnly {
+			return true
+		}
+	}
+	return false
 }
 
 // needsConfirmation reports whether this tier falls inside the confirm scope.
@@ -84,22 +143,79 @@ func (g Gate) needsConfirmation(a Access) bool {
 	return a == AccessWrite || a == AccessDestructive
 }
 
+// Outcome records how Decide reached its verdict, for the audit log.
+type Outcome string
+
+// Possible outcomes of a permission decision.
+const (
+	// OutcomeAllowed means the policy let the call run without asking.
+	OutcomeAllowed Outcome = "allowed"
+	// OutcomeApproved means the user was asked and said yes.
+	OutcomeApproved Outcome = "approved"
+	// OutcomeDeclined means the user was asked and said no.
+	OutcomeDeclined Outcome = "declined"
+	// OutcomeFallback means the client could not prompt and fallback=allow
+	// let the call run anyway.
+	OutcomeFallback Outcome = "fallback"
+	// OutcomeDenied means the policy, scope or a failed prompt refused the call.
+	OutcomeDenied Outcome = "denied"
+	// OutcomePending means the user has been asked and not yet answered.
+	OutcomePending Outcome = "pending"
+)
+
 // Authorize decides whether a tool call may proceed, prompting the user when
-// the policy requires it. A nil return means the call is allowed.
+// the policy requires it. A nil return means the call is allowed. tool may
+// name one argument as tool:argument, for the tool rules that do.
 func (g Gate) Authorize(ctx context.Context, c Confirmer, tool string, a Access) error {
+	_, err := g.Decide(ctx, c, tool, a)
+	return err
+}
+
+// Decide is Authorize, also reporting how the verdict was reached.
+func (g Gate) Decide(ctx context.Context, c Confirmer, tool string, a Access) (Outcome, error) {
+	// The argument comes from the model; keep it out of span names and
+	// metric labels, where every value would mint a new series.
+	name, arg, hasArg := strings.Cut(tool, ":")
+	attrs := []tracing.Attr{tracing.String("mcp.tool", name), tracing.String("arr.access", a.String())}
+	if hasArg {
+		attrs = append(attrs, tracing.String("mcp.tool.argument", arg))
+	}
+	ctx, span := tracing.Start(ctx, "authorize "+name, tracing.KindInternal, attrs...)
+	outcome, err := g.decide(ctx, c, tool, a)
+	span.SetAttrs(tracing.String("arr.decision", string(outcome)))
+	if outcome == OutcomePending {
+		span.End(nil)
+	} else {
+		span.End(err)
+	}
+	if outcome != OutcomeAllowed && outcome != OutcomePending {
+		metrics.PermissionDecisions.Inc(name, string(outcome))
+	}
+	return outcome, err
+}
+
+func (g Gate) decide(ctx context.Context, c Confirmer, tool string, a Access) (Outcome, error) {
+	if g.Scopes != nil && !a.grantedBy(g.Scopes) {
+		return OutcomeDenied, fmt.Errorf("%w: %s tool %s needs %s", ErrInsufficientScope, a, tool, a.Scope())
+	}
+	perms, ok := g.Perms.ForTool(tool)
+	if !ok {
+		return OutcomeDenied, fmt.Errorf("%w: %s is excluded by allowTools or denyTools", ErrToolNotPermitted, tool)
+	}
+	g.Perms = perms
 	if a == AccessRead {
-		return nil
+		return OutcomeAllowed, nil
 	}
 
 	switch g.Perms.Mode {
 	case config.ModeReadOnly:
-		return fmt.Errorf("%w: refusing %s tool %s", ErrReadOnly, a, tool)
+		return OutcomeDenied, fmt.Errorf("%w: refusing %s tool %s", ErrReadOnly, a, tool)
 	case config.ModeFull:
-		return nil
+		return OutcomeAllowed, nil
 	}
 
 	if !g.needsConfirmation(a) {
-		return nil
+		return OutcomeAllowed, nil
 	}
 
 	approved, err := c.Confirm(ctx, fmt.Sprintf(
@@ -109,15 +225,21 @@ func (g Gate) Authorize(ctx context.Context, c Confirmer, tool string, a Access)
 			// Failing closed matters: a client without elicitation would
 			// otherwise silently turn confirm mode into full write access.
 			if g.Perms.Fallback == config.FallbackAllow {
-				return nil
+				return OutcomeFallback, nil
 			}
-			return fmt.Errorf("%w: cannot confirm %s tool %s; set permissions.fallback=allow "+
+			return OutcomeDenied, fmt.Errorf("%w: cannot confirm %s tool %s; set permissions.fallback=allow "+
 				"or permissions.mode=full to permit it", ErrConfirmUnsupported, a, tool)
 		}
-		return fmt.Errorf("confirming %s: %w", tool, err)
+		if errors.Is(err, ErrConfirmPending) {
+			return OutcomePending, err
+		}
+		if errors.Is(err, ErrDeclined) {
+			return OutcomeDeclined, err
+		}
+		return OutcomeDenied, fmt.Errorf("confirming %s: %w", tool, err)
 	}
 	if !approved {
-		return fmt.Errorf("%w: %s", ErrDeclined, tool)
+		return OutcomeDeclined, fmt.Errorf("%w: %s", ErrDeclined, tool)
 	}
-	return nil
+	return OutcomeApproved, nil
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // fakeConfirmer stands in for an MCP client's elicitation support.
@@ -31,13 +32,13 @@ func gate(mode config.Mode, scope config.Scope, fb config.Fallback) Gate {
 func TestReadOnlyModeRegistersOnlyReadTools(t *testing.T) {
 	g := gate(config.ModeReadOnly, config.ScopeWrite, config.FallbackDeny)
 
-	if !g.Registers(AccessRead) {
+	if !g.Registers("sonarr_list_series", AccessRead) {
 		t.Error("read tools must be registered in readonly mode")
 	}
-	if g.Registers(AccessWrite) {
+	if g.Registers("sonarr_add_series", AccessWrite) {
 		t.Error("write tools must not be registered in readonly mode")
 	}
-	if g.Registers(AccessDestructive) {
+	if g.Registers("sonarr_delete_series", AccessDestructive) {
 		t.Error("destructive tools must not be registered in readonly mode")
 	}
 }
@@ -46,7 +47,7 @@ func TestFullModeRegistersEveryTool(t *testing.T) {
 	g := gate(config.ModeFull, config.ScopeWrite, config.FallbackDeny)
 
 	for _, a := range []Access{AccessRead, AccessWrite, AccessDestructive} {
-		if !g.Registers(a) {
+		if !g.Registers("sonarr_tool", a) {
 			t.Errorf("access %v must be registered in full mode", a)
 		}
 	}
@@ -170,3 +171,121 @@ func TestAnnotationsMarkReadAndDestructiveTools(t *testing.T) {
 		t.Error("destructive tools must set DestructiveHint")
 	}
 }
+
+// OAuth scopes are cumulative and apply before, and regardless of, the mode.
+func TestScopesLimitAccessTiers(t *testing.T) {
+	g := gate(config.ModeFull, config.ScopeWrite, config.FallbackDeny)
+	cases := []struct {
+		scopes []string
+		a      Access
+		allow  bool
+	}{
+		{[]string{ScopeRead}, AccessRead, true},
+		{[]string{ScopeRead}, AccessWrite, false},
+		{[]string{ScopeWrite}, AccessRead, true},
+		{[]string{ScopeWrite}, AccessDestructive, false},
+		{[]string{"openid", ScopeDestructive}, AccessDestructive, true},
+		{[]string{}, AccessRead, false},
+	}
+	for _, tc := range cases {
+		g.Scopes = tc.scopes
+		err := g.Authorize(context.Background(), &fakeConfirmer{}, "t", tc.a)
+		if tc.allow && err != nil {
+			t.Errorf("scopes %v, %s: unexpected error %v", tc.scopes, tc.a, err)
+		}
+		if !tc.allow && !errors.Is(err, ErrInsufficientScope) {
+			t.Errorf("scopes %v, %s: err = %v, want ErrInsufficientScope", tc.scopes, tc.a, err)
+		}
+	}
+}
+
+func TestToolRulesShapeRegistration(t *testing.T) {
+	g := Gate{Perms: config.Permissions{
+		Mode:      config.ModeReadOnly,
+		DenyTools: []string{"sonarr_list_releases"},
+		Tools: map[string]config.Mode{
+			"sonarr_add_*":               config.ModeConfirm,
+			"sonarr_run_command:RssSync": config.ModeFull,
+			"sonarr_run_command:Backup":  config.ModeReadOnly,
+		},
+	}}
+	for _, tc := range []struct {
+		tool string
+		a    Access
+		want bool
+	}{
+		{"sonarr_list_series", AccessRead, true},
+		{"sonarr_list_releases", AccessRead, false},
+		{"sonarr_add_series", AccessWrite, true},
+		{"sonarr_delete_series", AccessDestructive, false},
+		{"sonarr_run_command", AccessWrite, true},
+		{"", AccessRead, true},
+		{"", AccessWrite, false},
+	} {
+		if got := g.Registers(tc.tool, tc.a); got != tc.want {
+			t.Errorf("Registers(%q, %s) = %v, want %v", tc.tool, tc.a, got, tc.want)
+		}
+	}
+}
+
+func TestToolRulesApplyAtCallTime(t *testing.T) {
+	g := Gate{Perms: config.Permissions{
+		Mode: config.ModeConfirm, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny,
+		DenyTools: []string{"*_run_command:Backup"},
+		Tools:     map[string]config.Mode{"*_run_command:RssSync": config.ModeFull},
+	}}
+	c := &fakeConfirmer{unsupported: true}
+	ctx := context.Background()
+
+	if err := g.Authorize(ctx, c, "sonarr_run_command:RssSync", AccessWrite); err != nil {
+		t.Errorf("RssSync: %v, want it run without confirmation", err)
+	}
+	if err := g.Authorize(ctx, c, "sonarr_run_command:backup", AccessWrite); !errors.Is(err, ErrToolNotPermitted) {
+		t.Errorf("backup: err = %v, want ErrToolNotPermitted", err)
+	}
+	if err := g.Authorize(ctx, c, "sonarr_run_command:RefreshSeries", AccessWrite); !errors.Is(err, ErrConfirmUnsupported) {
+		t.Errorf("RefreshSeries: err = %v, want the usual confirmation", err)
+	}
+	if len(c.prompts) != 1 {
+		t.Errorf("prompted %d times, want once", len(c.prompts))
+	}
+}
+
+// "Add series but never delete them; run RssSync but not Backup."
+func TestToolRulesEndToEnd(t *testing.T) {
+	srv, paths := recordingArr(t, `{"id":1,"name":"RssSync","status":"queued"}`)
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, config.Permissions{
+		Mode: config.ModeFull, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny,
+		DenyTools: []string{"sonarr_delete_*", "sonarr_run_command:Backup"},
+	})
+	cs := connect(t, cfg)
+
+	names := toolNames(t, cs)
+	if !has(names, "sonarr_add_series") || !has(names, "sonarr_run_command") {
+		t.Errorf("allowed tools missing from %v", names)
+	}
+	if has(names, "sonarr_delete_series") {
+		t.Error("denied sonarr_delete_series is advertised")
+	}
+
+	run := func(command string) *mcp.CallToolResult {
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+			Name: "sonarr_run_command", Arguments: map[string]any{"name": command},
+		})
+		if err != nil {
+			t.Fatalf("CallTool %s: %v", command, err)
+		}
+		return res
+	}
+	if res := run("RssSync"); res.IsError {
+		t.Errorf("RssSync refused: %s", contentText(res))
+	}
+	if res := run("Backup"); !res.IsError || !strings.Contains(contentText(res), "denyTools") {
+		t.Errorf("Backup result = %s, want it refused by denyTools", contentText(res))
+	}
+	if len(*paths) != 1 {
+		t.Errorf("upstream saw %v, want only the RssSync command", *paths)
+	}
+}
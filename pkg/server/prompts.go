@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// promptMeta describes a prompt independently of the instructions it expands to.
+type promptMeta struct {
+	name        string
+	description string
+	service     string
+	// title, when set, describes a title argument; titleRequired makes the
+	// prompt refuse to expand without it.
+	title         string
+	titleRequired bool
+}
+
+// registerPrompts adds guided workflows for the configured services. Each
+// prompt expands into numbered steps naming only tools this deployment
+// registered, so readonly servers are never told to call a hidden tool.
+func registerPrompts(s *Server) {
+	registerPrompt(s, promptMeta{
+		name:          "sonarr_diagnose_series",
+		description:   "Work out why a TV series is not downloading in Sonarr.",
+		service:       "sonarr",
+		title:         "title of the series to investigate",
+		titleRequired: true,
+	}, diagnoseSeriesSteps)
+
+	registerPrompt(s, promptMeta{
+		name:          "radarr_diagnose_movie",
+		description:   "Work out why a movie is not downloading in Radarr.",
+		service:       "radarr",
+		title:         "title of the movie to investigate",
+		titleRequired: true,
+	}, diagnoseMovieSteps)
+
+	for _, svc := range []string{"sonarr", "radarr", "lidarr", "readarr"} {
+		registerPrompt(s, promptMeta{
+			name:        svc + "_cleanup_tags",
+			description: "Find " + svc + " tags nothing uses any more and offer to delete them.",
+			service:     svc,
+		}, cleanupTagsSteps)
+	}
+
+	registerPrompt(s, promptMeta{
+		name:        "bazarr_audit_subtitles",
+		description: "Audit subtitle coverage in Bazarr, for the whole library or one series.",
+		service:     "bazarr",
+		title:       "optional series title to focus the audit on",
+	}, auditSubtitlesSteps)
+}
+
+// registerPrompt adds one prompt, resolving its instance argument the same
+// way tools do so the expanded steps name a concrete instance.
+func registerPrompt(s *Server, meta promptMeta, build func(g *guide, title string)) {
+	if !s.registersForService(meta.service, "", AccessRead) {
+		return
+	}
+
+	args := []*mcp.PromptArgument{}
+	if meta.title != "" {
+		args = append(args, &mcp.PromptArgument{Name: "title", Description: meta.title, Required: meta.titleRequired})
+	}
+	args = append(args, &mcp.PromptArgument{
+		Name: "instance",
+		Description: "which configured instance to use; omit to use the default. One of: " +
+			strings.Join(s.config().InstanceNames(meta.service), ", "),
+	})
+
+	s.pass.prompts[meta.name] = true
+	s.mcp.AddPrompt(&mcp.Prompt{
+		Name:        meta.name,
+		Description: meta.description,
+		Arguments:   args,
+	}, func(_ context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		title := strings.TrimSpace(req.Params.Arguments["title"])
+		if meta.titleRequired && title == "" {
+			return nil, fmt.Errorf("%s needs a title argument", meta.name)
+		}
+		cfg := s.config()
+		inst, err := cfg.Resolve(meta.service, req.Params.Arguments["instance"])
+		if err != nil {
+			return nil, err
+		}
+
+		g := &guide{s: s, service: meta.service}
+		build(g, title)
+		if len(cfg.Services[meta.service]) > 1 {
+			g.line("Pass instance %q to every %s tool call.", inst.Name, meta.service)
+		}
+		return &mcp.GetPromptResult{
+			Description: meta.description,
+			Messages: []*mcp.PromptMessage{{
+				Role:    "user",
+				Content: &mcp.TextContent{Text: g.String()},
+			}},
+		}, nil
+	})
+}
+
+// guide accumulates prompt instructions as numbered steps.
+type guide struct {
+	s       *Server
+	service string
+	b       strings.Builder
+	steps   int
+}
+
+// line adds an unnumbered instruction.
+func (g *guide) line(format string, args ...any) {
+	fmt.Fprintf(&g.b, format+"\n", args...)
+}
+
+// step adds a numbered instruction naming tools, which fill the %s verbs in
+// text in order. The step is left out, and step reports false, unless every
+// tool is registered.
+func (g *guide) step(text string, tools ...string) bool {
+	names := make([]any, 0, len(tools))
+	for _, t := range tools {
+		if !g.s.advertised.Load().tools[t] {
+			return false
+		}
+		names = append(names, "`"+t+"`")
+	}
+	g.steps++
+	fmt.Fprintf(&g.b, "%d. "+text+"\n", append([]any{g.steps}, names...)...)
+	return true
+}
+
+// String returns the accumulated instructions.
+func (g *guide) String() string { return strings.TrimRight(g.b.String(), "\n") }
+
+func diagnoseSeriesSteps(g *guide, title string) {
+	g.line("Find out why the series %q is not downloading in Sonarr. Only read state until the cause is clear.", title)
+	g.step("Call %s and find the series by title. Note its id and whether it is monitored; "+
+		"if it is missing, it was never added, and %s can confirm the right match.",
+		"sonarr_list_series", "sonarr_search_series")
+	g.step("Call %s for that series id and list which monitored episodes have aired without a file.",
+		"sonarr_list_episodes")
+	g.step("Call %s to see whether any of those episodes are already downloading or stuck importing.",
+		"sonarr_queue")
+	g.step("If an item is stuck importing, call %s for it to see why Sonarr will not import the files.",
+		"sonarr_manual_import_candidates")
+	g.step("Call %s and look for recent grabs or failures for this series.", "sonarr_history")
+	g.step("Call %s for indexer, download client or root folder problems.", "sonarr_health")
+	g.step("Call %s for one missing episode and read the rejection reasons on each candidate release.",
+		"sonarr_list_releases")
+	g.step("Call %s to check whether the indexers Sonarr relies on are failing.", "prowlarr_indexer_stats")
+	g.line("Summarise the cause in one or two sentences.")
+	if !g.step("Offer %s or %s as the fix, and run either only after the user agrees.",
+		"sonarr_trigger_search", "sonarr_grab_release") {
+		g.line("This server cannot start searches, so describe the fix for the user to apply.")
+	}
+}
+
+func diagnoseMovieSteps(g *guide, title string) {
+	g.line("Find out why the movie %q is not downloading in Radarr. Only read state until the cause is clear.", title)
+	g.step("Call %s and find the movie by title. Note its id, whether it is monitored and whether it has a file; "+
+		"if it is missing, it was never added, and %s can confirm the right match.",
+		"radarr_list_movies", "radarr_search_movies")
+	g.step("Call %s to see whether it is already downloading or stuck importing.", "radarr_queue")
+	g.step("If it is stuck importing, call %s for it to see why Radarr will not import the files.",
+		"radarr_manual_import_candidates")
+	g.step("Call %s and look for recent grabs or failures for this movie.", "radarr_history")
+	g.step("Call %s for indexer, download client or root folder problems.", "radarr_health")
+	g.step("Call %s for the movie and read the rejection reasons on each candidate release.",
+		"radarr_list_releases")
+	g.step("Call %s to check whether the indexers Radarr relies on are failing.", "prowlarr_indexer_stats")
+	g.line("Summarise the cause in one or two sentences.")
+	if !g.step("Offer %s or %s as the fix, and run either only after the user agrees.",
+		"radarr_trigger_search", "radarr_grab_release") {
+		g.line("This server cannot start searches, so describe the fix for the user to apply.")
+	}
+}
+
+func cleanupTagsSteps(g *guide, _ string) {
+	svc := g.service
+	g.line("Clean up unused tags.")
+	g.step("Call %s. A tag whose every count is zero is unused.", svc+"_tag_details")
+	g.line("List the unused tags by label and ask the user which to remove.")
+	if !g.step("Call %s once per tag the user approves, by tag id.", svc+"_delete_tag") {
+		g.line("This server cannot delete tags, so stop after reporting them.")
+	}
+}
+
+func auditSubtitlesSteps(g *guide, title string) {
+	if title != "" {
+		g.line("Audit subtitle coverage for the series %q in Bazarr.", title)
+		g.step("Call %s and page through it until you find the series; note its sonarrSeriesId.",
+			"bazarr_list_series")
+		g.step("Call %s to see which languages are enabled.", "bazarr_list_languages")
+		g.step("Call %s for that series and list, per episode, the subtitles present and missing.",
+			"bazarr_list_episode_subtitles")
+	} else {
+		g.line("Audit subtitle coverage across the Bazarr library.")
+		g.step("Call %s for the overall count of episodes and movies missing subtitles.", "bazarr_badges")
+		g.step("Call %s to see which languages are enabled.", "bazarr_list_languages")
+		g.step("Call %s and %s, paging with start and length until the totals are covered, "+
+			"and group what is missing by language.", "bazarr_wanted_episodes", "bazarr_wanted_movies")
+	}
+	g.step("Call %s; throttled providers often explain gaps.", "bazarr_list_providers")
+	g.step("Call %s for configuration problems.", "bazarr_health")
+	g.line("Report coverage per language and the largest gaps.")
+	if title != "" {
+		g.step("Offer %s for the missing episodes and run it only after the user agrees.",
+			"bazarr_search_episode_subtitles")
+	} else {
+		g.step("Offer %s and %s for the worst gaps and run them only after the user agrees.",
+			"bazarr_search_episode_subtitles", "bazarr_search_movie_subtitles")
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// promptText expands a prompt and returns its single message.
+func promptText(t *testing.T, cs *mcp.ClientSession, name string, args map[string]string) string {
+	t.Helper()
+	res, err := cs.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("GetPrompt %s: %v", name, err)
+	}
+	if len(res.Messages) != 1 {
+		t.Fatalf("messages = %d, want 1", len(res.Messages))
+	}
+	text, ok := res.Messages[0].Content.(*mcp.TextContent)
+	if !ok {
+		t.Fatalf("content = %T, want text", res.Messages[0].Content)
+	}
+	return text.Text
+}
+
+func TestPromptsOnlyForConfiguredServices(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.ListPrompts(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListPrompts: %v", err)
+	}
+	var names []string
+	for _, p := range res.Prompts {
+		names = append(names, p.Name)
+	}
+	for _, want := range []string{"sonarr_diagnose_series", "sonarr_cleanup_tags"} {
+		if !has(names, want) {
+			t.Errorf("prompt %s missing from %v", want, names)
+		}
+	}
+	for _, unwanted := range []string{"radarr_diagnose_movie", "bazarr_audit_subtitles"} {
+		if has(names, unwanted) {
+			t.Errorf("prompt %s listed for an unconfigured service", unwanted)
+		}
+	}
+}
+
+func TestDiagnosePromptNamesRegisteredToolsAndInstance(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {
+			{Name: "main", URL: srv.URL, APIKey: "k", Default: true},
+			{Name: "anime", URL: srv.URL, APIKey: "k"},
+		},
+	}, permsFull))
+
+	text := promptText(t, cs, "sonarr_diagnose_series", map[string]string{"title": "Frieren", "instance": "anime"})
+	for _, want := range []string{`"Frieren"`, "`sonarr_list_series`", "`sonarr_list_releases`", "`sonarr_trigger_search`", `"anime"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt does not mention %s:\n%s", want, text)
+		}
+	}
+	// Prowlarr is not configured, so its tools must not be suggested.
+	if strings.Contains(text, "prowlarr_") {
+		t.Errorf("prompt references an unregistered prowlarr tool:\n%s", text)
+	}
+}
+
+func TestPromptLeavesOutToolsHiddenByReadOnlyMode(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"radarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, config.Permissions{Mode: config.ModeReadOnly}))
+
+	text := promptText(t, cs, "radarr_cleanup_tags", nil)
+	if !strings.Contains(text, "`radarr_tag_details`") {
+		t.Errorf("prompt does not mention radarr_tag_details:\n%s", text)
+	}
+	if strings.Contains(text, "radarr_delete_tag") {
+		t.Errorf("readonly prompt references the hidden delete tool:\n%s", text)
+	}
+}
+
+func TestPromptRejectsMissingTitleAndUnknownInstance(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	if _, err := cs.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: "sonarr_diagnose_series"}); err == nil {
+		t.Error("expected a missing title to be rejected")
+	}
+	_, err := cs.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "sonarr_diagnose_series",
+		Arguments: map[string]string{"title": "Frieren", "instance": "typo"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "main") {
+		t.Errorf("err = %v, want a rejection naming the valid instances", err)
+	}
+}
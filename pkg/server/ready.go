@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/logger"
+)
+
+// InstanceStatus is the result of pinging one configured instance.
+type InstanceStatus struct {
+	Service  string `json:"service"`
+	Instance string `json:"instance"`
+	// URL is left out of /ready, which is unauthenticated.
+	URL string `json:"-"`
+	OK  bool   `json:"ok"`
+	// Error is a short reason fit for /ready: unreachable, timed out,
+	// circuit open or the HTTP status. Detail is the full error, which names
+	// the upstream URL and may quote its response, for logs and --check.
+	Error  string `json:"error,omitempty"`
+	Detail string `json:"-"`
+}
+
+// CheckInstances pings every configured instance in parallel. Results are in
+// configuration order, services alphabetically.
+func CheckInstances(ctx context.Context, cfg *config.Config) []InstanceStatus {
+	var instances []*config.Instance
+	var results []InstanceStatus
+	for _, svc := range cfg.ConfiguredServices() {
+		if _, ok := arr.Specs[svc]; !ok {
+			continue
+		}
+		for i := range cfg.Services[svc] {
+			inst := &cfg.Services[svc][i]
+			instances = append(instances, inst)
+			results = append(results, InstanceStatus{Service: svc, Instance: inst.Name, URL: inst.URL})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		r, inst := &results[i], instances[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := newClient(arr.Specs[r.Service], inst)
+			defer client.CloseIdleConnections()
+			ctx, status := arr.RecordStatus(ctx)
+			if err := client.Ping(ctx); err != nil {
+				r.Error, r.Detail = pingReason(status(), err), err.Error()
+				return
+			}
+			r.OK = true
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// pingReason condenses a failed ping to the reason /ready shows.
+func pingReason(status int, err error) string {
+	switch {
+	case status != 0:
+		return fmt.Sprintf("HTTP %d", status)
+	case errors.Is(err, arr.ErrUnavailable):
+		return "circuit open"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	}
+	return "unreachable"
+}
+
+// readiness serves /ready, reusing one round of checks for the configured TTL
+// so a busy load balancer cannot turn probes into a stream of upstream calls.
+type readiness struct {
+	cfg func() *config.Config
+	log *logger.Logger
+
+	mu      sync.Mutex
+	checked time.Time
+	// against is the configuration results were checked under; a reload
+	// makes them stale whatever their age.
+	against *config.Config
+	results []InstanceStatus
+}
+
+type readyReport struct {
+	Status    string             `json:"status"`
+	Policy    config.ReadyPolicy `json:"policy"`
+	CheckedAt time.Time          `json:"checkedAt"`
+	Instances []InstanceStatus   `json:"instances"`
+}
+
+// report returns cached results, re-checking once they are older than the TTL
+// or the configuration has been reloaded.
+// Holding the lock across the check means concurrent probes share one round.
+func (r *readiness) report(ctx context.Context) readyReport {
+	cfg := r.cfg()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results == nil || r.against != cfg || time.Since(r.checked) >= cfg.Server.Readiness.CacheTTL {
+		r.results = CheckInstances(ctx, cfg)
+		for _, res := range r.results {
+			if !res.OK {
+				r.log.Warn("readiness: %s/%s: %s", res.Service, res.Instance, res.Detail)
+			}
+		}
+		r.checked = time.Now()
+		r.against = cfg
+	}
+
+	policy := cfg.Server.Readiness.Policy
+	rep := readyReport{Status: "ready", Policy: policy, CheckedAt: r.checked.UTC(), Instances: r.results}
+	if !ready(policy, r.results) {
+		rep.Status = "unready"
+	}
+	return rep
+}
+
+// ready applies the policy: under all every instance must answer, under any
+// one is enough.
+func ready(policy config.ReadyPolicy, results []InstanceStatus) bool {
+	up := 0
+	for _, r := range results {
+		if r.OK {
+			up++
+		}
+	}
+	if policy == config.ReadyAny {
+		return up > 0
+	}
+	return up == len(results)
+}
+
+func (r *readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Detached from the request so a probe timing out mid-check does not
+	// cache every instance as failed.
+	rep := r.report(context.WithoutCancel(req.Context()))
+	w.Header().Set("Content-Type", "application/json")
+	if rep.Status != "ready" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+)
+
+// readyCfg configures one reachable and one unreachable Sonarr.
+func readyCfg(t *testing.T, policy config.ReadyPolicy) (*config.Config, *int) {
+	t.Helper()
+	up, hits := fakeArr(t, `{"version":"4.0.0"}`)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {
+			{Name: "main", URL: up.URL, APIKey: "k", Default: true},
+			{Name: "anime", URL: down.URL, APIKey: "k"},
+		},
+	}, permsFull)
+	cfg.Server.Readiness = config.ReadinessConfig{Policy: policy, CacheTTL: time.Minute}
+	return cfg, hits
+}
+
+func getReady(t *testing.T, base string) (int, readyReport) {
+	t.Helper()
+	resp, err := http.Get(base + "/ready")
+	if err != nil {
+		t.Fatalf("GET /ready: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var rep readyReport
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil {
+		t.Fatalf("decoding /ready: %v", err)
+	}
+	return resp.StatusCode, rep
+}
+
+func TestReadyUnderPolicyAllFailsOnAnyDownInstance(t *testing.T) {
+	cfg, _ := readyCfg(t, config.ReadyAll)
+	code, rep := getReady(t, serveHTTP(t, cfg))
+
+	if code != http.StatusServiceUnavailable || rep.Status != "unready" {
+		t.Errorf("status = %d %q, want 503 unready", code, rep.Status)
+	}
+	if len(rep.Instances) != 2 {
+		t.Fatalf("instances = %+v, want both reported", rep.Instances)
+	}
+	for _, inst := range rep.Instances {
+		if wantOK := inst.Instance == "main"; inst.OK != wantOK || (inst.Error == "") != wantOK {
+			t.Errorf("instance %+v, want ok=%v with an error only when down", inst, wantOK)
+		}
+	}
+}
+
+func TestReadyUnderPolicyAnyNeedsOneInstance(t *testing.T) {
+	cfg, _ := readyCfg(t, config.ReadyAny)
+	if code, rep := getReady(t, serveHTTP(t, cfg)); code != http.StatusOK || rep.Status != "ready" {
+		t.Errorf("status = %d %q, want 200 ready with one instance up", code, rep.Status)
+	}
+}
+
+func TestReadyCachesChecksForTheTTL(t *testing.T) {
+	cfg, hits := readyCfg(t, config.ReadyAny)
+	base := serveHTTP(t, cfg)
+
+	getReady(t, base)
+	getReady(t, base)
+	if *hits != 1 {
+		t.Errorf("upstream pinged %d times for two probes, want 1 within the TTL", *hits)
+	}
+}
+
+// --check and /ready connect with each instance's TLS settings, so a service
+// behind a private CA passes once its CA is configured.
+func TestCheckInstancesUsesInstanceTLS(t *testing.T) {
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(tlsSrv.Close)
+
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsSrv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(cfgFile, []byte(`
+services:
+  sonarr:
+    - name: private
+      url: `+tlsSrv.URL+`
+      apiKey: k
+      tls:
+        caFile: `+ca+`
+    - name: untrusted
+      url: `+tlsSrv.URL+`
+      apiKey: k
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	results := CheckInstances(context.Background(), cfg)
+	if len(results) != 2 || !results[0].OK || results[1].OK {
+		t.Errorf("results = %+v, want only the instance with the CA to pass", results)
+	}
+}
+
+// /ready is unauthenticated, so a failure is a short reason that names
+// neither the upstream URL nor anything it answered with.
+func TestReadyReasonsHideTheUpstream(t *testing.T) {
+	refusing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad key for http://internal-host", http.StatusUnauthorized)
+	}))
+	t.Cleanup(refusing.Close)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {
+			{Name: "main", URL: refusing.URL, APIKey: "k", Default: true},
+			{Name: "anime", URL: down.URL, APIKey: "k"},
+		},
+	}, permsFull)
+	cfg.Server.Readiness = config.ReadinessConfig{Policy: config.ReadyAll, CacheTTL: time.Minute}
+
+	_, rep := getReady(t, serveHTTP(t, cfg))
+	want := map[string]string{"main": "HTTP 401", "anime": "unreachable"}
+	for _, inst := range rep.Instances {
+		if inst.Error != want[inst.Instance] {
+			t.Errorf("%s: error = %q, want %q", inst.Instance, inst.Error, want[inst.Instance])
+		}
+	}
+	for _, r := range CheckInstances(context.Background(), cfg) {
+		if !strings.Contains(r.Detail, "127.0.0.1") && !strings.Contains(r.Detail, "internal-host") {
+			t.Errorf("%s: detail = %q, want the full error for --check", r.Instance, r.Detail)
+		}
+	}
+}
@@ -12,15 +12,23 @@ import (
 func registerAll(s *Server) {
 	registerSonarr(s)
 	registerRadarr(s)
+	registerLidarr(s)
+	registerReadarr(s)
 	registerProwlarr(s)
 	registerBazarr(s)
 
 	registerOperations(s, "sonarr", arr.SonarrSpec, operationOpts{hasQueue: true})
 	registerOperations(s, "radarr", arr.RadarrSpec, operationOpts{hasQueue: true})
+	registerOperations(s, "lidarr", arr.LidarrSpec, operationOpts{hasQueue: true})
+	registerOperations(s, "readarr", arr.ReadarrSpec, operationOpts{hasQueue: true})
 	registerOperations(s, "prowlarr", arr.ProwlarrSpec, operationOpts{hasQueue: false})
 
 	registerMedia(s, "sonarr", arr.SonarrSpec, mediaOpts{noun: "series"})
 	registerMedia(s, "radarr", arr.RadarrSpec, mediaOpts{noun: "movies"})
+	registerMedia(s, "lidarr", arr.LidarrSpec, mediaOpts{noun: "artists"})
+	registerMedia(s, "readarr", arr.ReadarrSpec, mediaOpts{noun: "authors"})
+
+	registerResources(s)
 }
 
 func registerSonarr(s *Server) {
@@ -31,8 +39,8 @@ func registerSonarr(s *Server) {
 		name:        "sonarr_list_series",
 		description: "List the TV series in a Sonarr library.",
 		access:      AccessRead,
-	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (SeriesList, error) {
-		series, err := arr.SonarrListSeries(ctx, c)
+	}, func(ctx context.Context, c *arr.Client, in ListArgs) (SeriesList, error) {
+		series, err := arr.SonarrListSeries(in.context(ctx), c)
 		return SeriesList{Series: series, Count: len(series)}, err
 	})
 
@@ -92,6 +100,10 @@ func registerSonarr(s *Server) {
 		name:        "sonarr_delete_series",
 		description: "Remove a series from Sonarr, optionally deleting its files from disk.",
 		access:      AccessDestructive,
+		title: func(ctx context.Context, c *arr.Client, id int) (string, error) {
+			series, err := arr.SonarrGetSeries(ctx, c, id)
+			return series.Title, err
+		},
 	}, func(ctx context.Context, c *arr.Client, in DeleteArgs) (Deleted, error) {
 		if err := arr.SonarrDeleteSeries(ctx, c, in.ID, in.DeleteFiles); err != nil {
 			return Deleted{ID: in.ID}, err
@@ -190,16 +202,44 @@ func registerSonarr(s *Server) {
 		description: "Start an indexer search for a series, one of its seasons, or specific episodes. " +
 			"Give episodeIds to search episodes, seasonNumber to search a season, or neither to search the whole series.",
 		access: AccessWrite,
-	}, func(ctx context.Context, c *arr.Client, in SearchScopeArgs) (arr.CommandResult, error) {
-		return arr.SonarrTriggerSearch(ctx, c, in.SeriesID, in.SeasonNumber, in.EpisodeIDs)
+	}, func(ctx context.Context, c *arr.Client, in SearchScopeArgs) (CommandOutcome, error) {
+		cmd, err := arr.SonarrTriggerSearch(ctx, c, in.SeriesID, in.SeasonNumber, in.EpisodeIDs)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: "sonarr_list_releases",
+		description: "Search indexers interactively for one episode, or for a whole season when given seriesId and seasonNumber. " +
+			"Lists candidates with quality, custom format score, size, indexer, seeders and rejection reasons, " +
+			"best first by Sonarr's own ranking. Nothing is grabbed; pick one with sonarr_grab_release.",
+		access: AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in EpisodeReleasesArgs) (CandidateList, error) {
+		releases, total, err := arr.SonarrListReleases(ctx, c, in.SeriesID, in.SeasonNumber, in.EpisodeID, in.Limit)
+		return CandidateList{Releases: releases, Count: len(releases), Total: total}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: "sonarr_grab_release",
+		description: "Send one release from sonarr_list_releases to the download client, even if Sonarr rejected it. " +
+			"Run the search first on the same instance: Sonarr only remembers recent results.",
+		access: AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in GrabReleaseArgs) (arr.Release, error) {
+		return arr.GrabRelease(ctx, c, in.GUID, in.IndexerID)
 	})
 
 	register(s, svc, spec, toolMeta{
 		name:        "sonarr_refresh_series",
 		description: "Rescan one series' metadata and files on disk.",
 		access:      AccessWrite,
-	}, func(ctx context.Context, c *arr.Client, in EpisodesArgs) (arr.CommandResult, error) {
-		return arr.SonarrRefreshSeries(ctx, c, in.SeriesID)
+	}, func(ctx context.Context, c *arr.Client, in RefreshSeriesArgs) (CommandOutcome, error) {
+		cmd, err := arr.SonarrRefreshSeries(ctx, c, in.SeriesID)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
 	})
 
 	register(s, svc, spec, toolMeta{
@@ -229,8 +269,8 @@ func registerRadarr(s *Server) {
 		name:        "radarr_list_movies",
 		description: "List the movies in a Radarr library.",
 		access:      AccessRead,
-	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (MovieList, error) {
-		movies, err := arr.RadarrListMovies(ctx, c)
+	}, func(ctx context.Context, c *arr.Client, in ListArgs) (MovieList, error) {
+		movies, err := arr.RadarrListMovies(in.context(ctx), c)
 		return MovieList{Movies: movies, Count: len(movies)}, err
 	})
 
@@ -290,6 +330,10 @@ func registerRadarr(s *Server) {
 		name:        "radarr_delete_movie",
 		description: "Remove a movie from Radarr, optionally deleting its files from disk.",
 		access:      AccessDestructive,
+		title: func(ctx context.Context, c *arr.Client, id int) (string, error) {
+			movie, err := arr.RadarrGetMovie(ctx, c, id)
+			return movie.Title, err
+		},
 	}, func(ctx context.Context, c *arr.Client, in DeleteArgs) (Deleted, error) {
 		if err := arr.RadarrDeleteMovie(ctx, c, in.ID, in.DeleteFiles); err != nil {
 			return Deleted{ID: in.ID}, err
@@ -366,24 +410,52 @@ func registerRadarr(s *Server) {
 		name:        "radarr_trigger_search",
 		description: "Start an indexer search for specific movies.",
 		access:      AccessWrite,
-	}, func(ctx context.Context, c *arr.Client, in MovieIDsArgs) (arr.CommandResult, error) {
-		return arr.RadarrTriggerSearch(ctx, c, in.MovieIDs)
+	}, func(ctx context.Context, c *arr.Client, in MovieIDsArgs) (CommandOutcome, error) {
+		cmd, err := arr.RadarrTriggerSearch(ctx, c, in.MovieIDs)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: "radarr_list_releases",
+		description: "Search indexers interactively for one movie. " +
+			"Lists candidates with quality, custom format score, size, indexer, seeders and rejection reasons, " +
+			"best first by Radarr's own ranking. Nothing is grabbed; pick one with radarr_grab_release.",
+		access: AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in MovieReleasesArgs) (CandidateList, error) {
+		releases, total, err := arr.RadarrListReleases(ctx, c, in.MovieID, in.Limit)
+		return CandidateList{Releases: releases, Count: len(releases), Total: total}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: "radarr_grab_release",
+		description: "Send one release from radarr_list_releases to the download client, even if Radarr rejected it. " +
+			"Run the search first on the same instance: Radarr only remembers recent results.",
+		access: AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in GrabReleaseArgs) (arr.Release, error) {
+		return arr.GrabRelease(ctx, c, in.GUID, in.IndexerID)
 	})
 
 	register(s, svc, spec, toolMeta{
 		name:        "radarr_refresh_movies",
 		description: "Rescan metadata and files on disk for specific movies.",
 		access:      AccessWrite,
-	}, func(ctx context.Context, c *arr.Client, in MovieIDsArgs) (arr.CommandResult, error) {
-		return arr.RadarrRefreshMovies(ctx, c, in.MovieIDs)
+	}, func(ctx context.Context, c *arr.Client, in MovieIDsArgs) (CommandOutcome, error) {
+		cmd, err := arr.RadarrRefreshMovies(ctx, c, in.MovieIDs)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
 	})
 
 	register(s, svc, spec, toolMeta{
 		name:        "radarr_list_collections",
 		description: "List the TMDB collections Radarr tracks, with how many movies each holds and how many are missing.",
 		access:      AccessRead,
-	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (CollectionList, error) {
-		collections, err := arr.RadarrListCollections(ctx, c)
+	}, func(ctx context.Context, c *arr.Client, in ListArgs) (CollectionList, error) {
+		collections, err := arr.RadarrListCollections(in.context(ctx), c)
 		return CollectionList{Collections: collections, Count: len(collections)}, err
 	})
 
@@ -442,8 +514,8 @@ func registerProwlarr(s *Server) {
 }
 
 // registerOperations adds the operational tools every *arr service shares.
-// Sonarr, Radarr and Prowlarr expose the same /health, /history and /command
-// endpoints, so these are written once and registered per service.
+// Sonarr, Radarr, Lidarr, Readarr and Prowlarr expose the same /health,
+// /history and /command endpoints, so these are written once and registered per service.
 func registerOperations(s *Server, svc string, spec arr.ServiceSpec, opts operationOpts) {
 	register(s, svc, spec, toolMeta{
 		name:        svc + "_health",
@@ -463,12 +535,36 @@ func registerOperations(s *Server, svc string, spec arr.ServiceSpec, opts operat
 		return HistoryList{Records: records, Count: len(records)}, err
 	})
 
+	// Advertise every command some instance allows; each call is then
+	// checked against its own instance's list.
+	if commands := allowedCommands(s.config(), svc, spec); len(commands) > 0 {
+		register(s, svc, spec, toolMeta{
+			name: svc + "_run_command",
+			description: "Trigger a background command in " + svc + ", such as RssSync. Only the commands " +
+				"listed for name are allowed. " +
+				"Set wait to block until it finishes and get its final status, duration and any error.",
+			access: AccessWrite,
+			enums:  map[string][]string{"name": commands},
+		}, func(ctx context.Context, c *arr.Client, in CommandArgs) (CommandOutcome, error) {
+			cmd, err := arr.RunCommand(ctx, c, in.Name, nil)
+			if err != nil {
+				return CommandOutcome{}, err
+			}
+			return awaitCommand(ctx, c, cmd, in.WaitArg)
+		})
+	}
+
 	register(s, svc, spec, toolMeta{
-		name:        svc + "_run_command",
-		description: "Trigger a background command in " + svc + ", such as RefreshSeries, RssSync or Backup.",
-		access:      AccessWrite,
-	}, func(ctx context.Context, c *arr.Client, in CommandArgs) (arr.CommandResult, error) {
-		return arr.RunCommand(ctx, c, in.Name, nil)
+		name: svc + "_command_status",
+		description: "Report the status, duration and any error of a command started earlier by a run, search, " +
+			"refresh or import tool. Set wait to block until it finishes.",
+		access: AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in CommandStatusArgs) (CommandOutcome, error) {
+		cmd, err := arr.GetCommand(ctx, c, in.ID)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
 	})
 
 	if !opts.hasQueue {
@@ -503,6 +599,30 @@ func registerOperations(s *Server, svc string, spec arr.ServiceSpec, opts operat
 		}
 		return Deleted{ID: in.ID, Deleted: true}, nil
 	})
+
+	register(s, svc, spec, toolMeta{
+		name: svc + "_manual_import_candidates",
+		description: "List the files in a download or folder that " + svc + " could import, with the mapping it proposes " +
+			"and why it will not import each one on its own. Use this for queue items stuck waiting to import.",
+		access: AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in ImportScopeArgs) (ImportCandidateList, error) {
+		candidates, err := arr.ListImportCandidates(ctx, c, in.DownloadID, in.Folder)
+		return ImportCandidateList{Candidates: candidates, Count: len(candidates)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: svc + "_manual_import",
+		description: "Import files from " + svc + "_manual_import_candidates with explicit mappings, overriding the service's " +
+			"own proposal and rejections. Give the same downloadId or folder the candidates were listed for.",
+		access: AccessWrite,
+		enums:  map[string][]string{"importMode": arr.ImportModes},
+	}, func(ctx context.Context, c *arr.Client, in ManualImportArgs) (CommandOutcome, error) {
+		cmd, err := arr.ManualImport(ctx, c, in.DownloadID, in.Folder, in.ImportMode, in.Files)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
+	})
 }
 
 // operationOpts records which shared endpoints a service actually implements.
@@ -512,15 +632,18 @@ type operationOpts struct {
 }
 
 // mediaOpts records how a media service names the things it manages, so the
-// tools registered for Sonarr and Radarr describe themselves accurately.
+// tools registered for Sonarr, Radarr, Lidarr and Readarr describe themselves
+// accurately.
 type mediaOpts struct {
-	// noun is what the service manages: "series" or "movies".
+	// noun is what the service manages: "series", "movies", "artists" or
+	// "authors".
 	noun string
 }
 
-// registerMedia adds the tools Sonarr and Radarr share. Both expose the same
-// settings, tag and library-maintenance endpoints under /api/v3, so registering
-// them from one place is what keeps the two services at parity.
+// registerMedia adds the tools Sonarr, Radarr, Lidarr and Readarr share. All
+// four expose the same settings, tag and library-maintenance endpoints (Lidarr
+// and Readarr under /api/v1 rather than /api/v3), so registering them from one place is what keeps the
+// services at parity.
 func registerMedia(s *Server, svc string, spec arr.ServiceSpec, opts mediaOpts) {
 	registerTags(s, svc, spec, opts)
 	registerSettings(s, svc, spec, opts)
@@ -560,6 +683,10 @@ func registerTags(s *Server, svc string, spec arr.ServiceSpec, opts mediaOpts) {
 		description: "Delete a tag from " + svc + ". This detaches it from every " +
 			opts.noun + ", profile and indexer that carried it.",
 		access: AccessDestructive,
+		title: func(ctx context.Context, c *arr.Client, id int) (string, error) {
+			tag, err := arr.GetTag(ctx, c, id)
+			return tag.Label, err
+		},
 	}, func(ctx context.Context, c *arr.Client, in IDArgs) (Deleted, error) {
 		if err := arr.DeleteTag(ctx, c, in.ID); err != nil {
 			return Deleted{ID: in.ID}, err
@@ -570,14 +697,14 @@ func registerTags(s *Server, svc string, spec arr.ServiceSpec, opts mediaOpts) {
 
 // registerSettings adds the profile and configuration listings. These are the
 // settings a power user asks about by name, and every one of them is identical
-// between Sonarr and Radarr apart from the wording of the description.
+// between the media services apart from the wording of the description.
 func registerSettings(s *Server, svc string, spec arr.ServiceSpec, opts mediaOpts) {
 	register(s, svc, spec, toolMeta{
 		name:        svc + "_list_custom_formats",
 		description: "List " + svc + " custom formats with the number of rules in each. Custom formats score releases during grabbing.",
 		access:      AccessRead,
-	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (CustomFormatList, error) {
-		formats, err := arr.ListCustomFormats(ctx, c)
+	}, func(ctx context.Context, c *arr.Client, in ListArgs) (CustomFormatList, error) {
+		formats, err := arr.ListCustomFormats(in.context(ctx), c)
 		return CustomFormatList{Formats: formats, Count: len(formats)}, err
 	})
 
@@ -653,8 +780,8 @@ func registerSettings(s *Server, svc string, spec arr.ServiceSpec, opts mediaOpt
 	})
 }
 
-// registerLibraryOps adds the blocklist and system views both media services
-// share. Sonarr and Radarr expose them identically.
+// registerLibraryOps adds the blocklist and system views the media services
+// share. Sonarr, Radarr, Lidarr and Readarr expose them identically.
 func registerLibraryOps(s *Server, svc string, spec arr.ServiceSpec, opts mediaOpts) {
 	register(s, svc, spec, toolMeta{
 		name:        svc + "_blocklist",
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+)
+
+// registerLidarr adds the music library tools. Queue, history, tags, settings
+// and commands come from registerOperations and registerMedia like they do for
+// Sonarr and Radarr; only the artist and album resources are Lidarr's own.
+func registerLidarr(s *Server) {
+	const svc = "lidarr"
+	spec := arr.LidarrSpec
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_list_artists",
+		description: "List the artists in a Lidarr library.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (ArtistList, error) {
+		artists, err := arr.LidarrListArtists(ctx, c)
+		return ArtistList{Artists: artists, Count: len(artists)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_search_artists",
+		description: "Search for artists to add to Lidarr. Returns foreignArtistId values for lidarr_add_artist.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in SearchArgs) (ArtistList, error) {
+		artists, err := arr.LidarrLookupArtists(ctx, c, in.Query)
+		return ArtistList{Artists: artists, Count: len(artists)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_list_albums",
+		description: "List the albums of one artist, with how many tracks of each are on disk.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in ArtistArgs) (AlbumList, error) {
+		albums, err := arr.LidarrListAlbums(ctx, c, in.ArtistID)
+		return AlbumList{Albums: albums, Count: len(albums)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_search_albums",
+		description: "Search MusicBrainz for albums matching a title.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in SearchArgs) (AlbumList, error) {
+		albums, err := arr.LidarrLookupAlbums(ctx, c, in.Query)
+		return AlbumList{Albums: albums, Count: len(albums)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_list_quality_profiles",
+		description: "List Lidarr quality profiles. Needed before adding an artist.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (ProfileList, error) {
+		profiles, err := arr.ListQualityProfiles(ctx, c)
+		return ProfileList{Profiles: profiles}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_list_metadata_profiles",
+		description: "List Lidarr metadata profiles, which decide which release types are tracked. Needed before adding an artist.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (MetadataProfileList, error) {
+		profiles, err := arr.ListMetadataProfiles(ctx, c)
+		return MetadataProfileList{Profiles: profiles}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_list_root_folders",
+		description: "List Lidarr root folders. Needed before adding an artist.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (FolderList, error) {
+		folders, err := arr.ListRootFolders(ctx, c)
+		return FolderList{Folders: folders}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_system_status",
+		description: "Report version and health information for a Lidarr instance.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (arr.SystemStatus, error) {
+		return arr.GetSystemStatus(ctx, c)
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_add_artist",
+		description: "Add an artist to a Lidarr library.",
+		access:      AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in AddArtistArgs) (arr.Artist, error) {
+		req := arr.AddArtistRequest{
+			ForeignArtistID:   in.ForeignArtistID,
+			ArtistName:        in.ArtistName,
+			QualityProfileID:  in.QualityProfileID,
+			MetadataProfileID: in.MetadataProfileID,
+			RootFolderPath:    in.RootFolderPath,
+			Monitored:         true,
+		}
+		req.AddOptions.Monitor = "all"
+		req.AddOptions.SearchForMissingAlbums = in.SearchNow
+		return arr.LidarrAddArtist(ctx, c, req)
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_delete_artist",
+		description: "Remove an artist from Lidarr, optionally deleting its files from disk.",
+		access:      AccessDestructive,
+		title: func(ctx context.Context, c *arr.Client, id int) (string, error) {
+			artist, err := arr.LidarrGetArtist(ctx, c, id)
+			return artist.ArtistName, err
+		},
+	}, func(ctx context.Context, c *arr.Client, in DeleteArgs) (Deleted, error) {
+		if err := arr.LidarrDeleteArtist(ctx, c, in.ID, in.DeleteFiles); err != nil {
+			return Deleted{ID: in.ID}, err
+		}
+		return Deleted{ID: in.ID, Deleted: true}, nil
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: "lidarr_edit_artists",
+		description: "Change monitoring, quality or metadata profile, tags or root folder for one or more artists at once. " +
+			"Omitted fields are left untouched.",
+		access: AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in EditArtistsArgs) (ArtistList, error) {
+		artists, err := arr.LidarrEditArtists(ctx, c, arr.ArtistEditRequest{
+			ArtistIDs:         in.ArtistIDs,
+			Monitored:         in.Monitored,
+			QualityProfileID:  in.QualityProfileID,
+			MetadataProfileID: in.MetadataProfileID,
+			RootFolderPath:    in.RootFolderPath,
+			MonitorNewItems:   in.MonitorNewItems,
+			Tags:              in.Tags,
+			ApplyTags:         in.ApplyTags,
+			MoveFiles:         in.MoveFiles,
+		})
+		return ArtistList{Artists: artists, Count: len(artists)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_monitor_albums",
+		description: "Monitor or unmonitor specific albums. Unmonitored albums are never searched for.",
+		access:      AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in AlbumMonitorArgs) (Updated, error) {
+		if err := arr.LidarrMonitorAlbums(ctx, c, in.AlbumIDs, in.Monitored); err != nil {
+			return Updated{}, err
+		}
+		return Updated{Updated: len(in.AlbumIDs)}, nil
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_wanted_missing",
+		description: "List monitored albums that have been released but have no files, with the library-wide total.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in LimitArgs) (AlbumList, error) {
+		albums, total, err := arr.LidarrWantedMissing(ctx, c, in.Limit)
+		return AlbumList{Albums: albums, Count: len(albums), Total: total}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_wanted_cutoff",
+		description: "List monitored albums whose files are below the quality cutoff and would still be upgraded.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in LimitArgs) (AlbumList, error) {
+		albums, total, err := arr.LidarrWantedCutoff(ctx, c, in.Limit)
+		return AlbumList{Albums: albums, Count: len(albums), Total: total}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: "lidarr_trigger_search",
+		description: "Start an indexer search for specific albums, or for every monitored album of an artist. " +
+			"Give albumIds to search albums, or only artistId to search the whole artist.",
+		access: AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in AlbumSearchArgs) (CommandOutcome, error) {
+		cmd, err := arr.LidarrTriggerSearch(ctx, c, in.ArtistID, in.AlbumIDs)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "lidarr_refresh_artist",
+		description: "Rescan one artist's metadata and files on disk.",
+		access:      AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in RefreshArtistArgs) (CommandOutcome, error) {
+		cmd, err := arr.LidarrRefreshArtist(ctx, c, in.ArtistID)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
+	})
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+)
+
+// registerReadarr adds the book library tools. Readarr keeps ebooks and
+// audiobooks in separate instances, so both are covered by configuring two
+// instances rather than by anything here. Queue, history, tags, settings and
+// commands come from registerOperations and registerMedia.
+func registerReadarr(s *Server) {
+	const svc = "readarr"
+	spec := arr.ReadarrSpec
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_list_authors",
+		description: "List the authors in a Readarr library.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (AuthorList, error) {
+		authors, err := arr.ReadarrListAuthors(ctx, c)
+		return AuthorList{Authors: authors, Count: len(authors)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_search_authors",
+		description: "Search for authors to add to Readarr. Returns foreignAuthorId values for readarr_add_author.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in SearchArgs) (AuthorList, error) {
+		authors, err := arr.ReadarrLookupAuthors(ctx, c, in.Query)
+		return AuthorList{Authors: authors, Count: len(authors)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_list_books",
+		description: "List the books of one author, with whether each is on disk.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in AuthorArgs) (BookList, error) {
+		books, err := arr.ReadarrListBooks(ctx, c, in.AuthorID)
+		return BookList{Books: books, Count: len(books)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_search_books",
+		description: "Search the metadata source for books matching a title or ISBN.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in SearchArgs) (BookList, error) {
+		books, err := arr.ReadarrLookupBooks(ctx, c, in.Query)
+		return BookList{Books: books, Count: len(books)}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_list_quality_profiles",
+		description: "List Readarr quality profiles. Needed before adding an author.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (ProfileList, error) {
+		profiles, err := arr.ListQualityProfiles(ctx, c)
+		return ProfileList{Profiles: profiles}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_list_metadata_profiles",
+		description: "List Readarr metadata profiles, which decide which of an author's books are tracked. Needed before adding an author.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (MetadataProfileList, error) {
+		profiles, err := arr.ListMetadataProfiles(ctx, c)
+		return MetadataProfileList{Profiles: profiles}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_list_root_folders",
+		description: "List Readarr root folders. Needed before adding an author.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (FolderList, error) {
+		folders, err := arr.ListRootFolders(ctx, c)
+		return FolderList{Folders: folders}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_system_status",
+		description: "Report version and health information for a Readarr instance.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, _ EmptyArgs) (arr.SystemStatus, error) {
+		return arr.GetSystemStatus(ctx, c)
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_add_author",
+		description: "Add an author to a Readarr library.",
+		access:      AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in AddAuthorArgs) (arr.Author, error) {
+		req := arr.AddAuthorRequest{
+			ForeignAuthorID:   in.ForeignAuthorID,
+			AuthorName:        in.AuthorName,
+			QualityProfileID:  in.QualityProfileID,
+			MetadataProfileID: in.MetadataProfileID,
+			RootFolderPath:    in.RootFolderPath,
+			Monitored:         true,
+		}
+		req.AddOptions.Monitor = "all"
+		req.AddOptions.SearchForMissingBooks = in.SearchNow
+		return arr.ReadarrAddAuthor(ctx, c, req)
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_monitor_books",
+		description: "Monitor or unmonitor specific books. Unmonitored books are never searched for.",
+		access:      AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in BookMonitorArgs) (Updated, error) {
+		if err := arr.ReadarrMonitorBooks(ctx, c, in.BookIDs, in.Monitored); err != nil {
+			return Updated{}, err
+		}
+		return Updated{Updated: len(in.BookIDs)}, nil
+	})
+
+	register(s, svc, spec, toolMeta{
+		name:        "readarr_wanted_missing",
+		description: "List monitored books that have been released but have no files, with the library-wide total.",
+		access:      AccessRead,
+	}, func(ctx context.Context, c *arr.Client, in LimitArgs) (BookList, error) {
+		books, total, err := arr.ReadarrWantedMissing(ctx, c, in.Limit)
+		return BookList{Books: books, Count: len(books), Total: total}, err
+	})
+
+	register(s, svc, spec, toolMeta{
+		name: "readarr_trigger_search",
+		description: "Start an indexer search for specific books, or for every monitored book of an author. " +
+			"Give bookIds to search books, or only authorId to search the whole author.",
+		access: AccessWrite,
+	}, func(ctx context.Context, c *arr.Client, in BookSearchArgs) (CommandOutcome, error) {
+		cmd, err := arr.ReadarrTriggerSearch(ctx, c, in.AuthorID, in.BookIDs)
+		if err != nil {
+			return CommandOutcome{}, err
+		}
+		return awaitCommand(ctx, c, cmd, in.WaitArg)
+	})
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+)
+
+// catalog is what one registration pass advertised, by tool and prompt name,
+// resource URI and resource URI template. known holds every tool this build
+// has, advertised or not.
+type catalog struct {
+	tools, prompts, resources, templates map[string]bool
+	known                                []string
+}
+
+func newCatalog() *catalog {
+	return &catalog{tools: map[string]bool{}, prompts: map[string]bool{}, resources: map[string]bool{}, templates: map[string]bool{}}
+}
+
+// advertise registers everything the running configuration supports. Tools,
+// prompts and resources that already exist are replaced, so their schemas
+// and descriptions pick up changed instance names.
+func (s *Server) advertise() *catalog {
+	s.pass = newCatalog()
+	registerAll(s)
+	registerPrompts(s)
+	c := s.pass
+	s.pass = nil
+	s.advertised.Store(c)
+	s.warnUnmatchedToolRules(c.known)
+	return c
+}
+
+// warnUnmatchedToolRules logs each tool rule pattern, in any permission
+// block, that names no tool this build has.
+func (s *Server) warnUnmatchedToolRules(known []string) {
+	cfg := s.config()
+	check := func(field string, p *config.Permissions) {
+		if p == nil {
+			return
+		}
+		for _, pattern := range p.UnmatchedToolPatterns(known) {
+			s.log.Warn("%s: tool pattern %q matches no tool; check it for typos", field, pattern)
+		}
+	}
+	check("permissions", &cfg.Permissions)
+	for _, svc := range cfg.ConfiguredServices() {
+		for _, inst := range cfg.Services[svc] {
+			check(svc+"."+inst.Name+".permissions", inst.Permissions)
+		}
+	}
+	for _, tok := range cfg.Server.Auth.Tokens {
+		check("server.auth.tokens."+tok.Name+".permissions", tok.Permissions)
+	}
+}
+
+// Reload swaps in cfg, which the caller has already loaded and validated,
+// without dropping connected sessions. Tools, prompts and resources are
+// re-registered to match it and those it no longer supports are withdrawn;
+// the SDK tells every session its lists changed. Calls already running finish
+// under the configuration they started with.
+//
+// Some settings are fixed once the server is listening. Reload leaves those
+// as they were and returns their names, so the caller can say a restart is
+// needed to apply them.
+func (s *Server) Reload(cfg *config.Config) []string {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	old := s.cfg.Load()
+	restart := restartRequired(old, cfg)
+	cfg = keepStartupSettings(old, cfg)
+	s.cfg.Store(cfg)
+	s.clients.setPolicy(cfg.Server.Upstream)
+	s.clients.retain(cfg)
+	before := s.advertised.Load()
+	after := s.advertise()
+
+	s.mcp.RemoveTools(withdrawn(before.tools, after.tools)...)
+	s.mcp.RemovePrompts(withdrawn(before.prompts, after.prompts)...)
+	s.mcp.RemoveResources(withdrawn(before.resources, after.resources)...)
+	s.mcp.RemoveResourceTemplates(withdrawn(before.templates, after.templates)...)
+	return restart
+}
+
+// keepStartupSettings returns a copy of cfg carrying over from old the
+// settings restartRequired names, so the running configuration matches what
+// the listener was built with. The HTTP handler only checks credentials if
+// auth was on at startup, so a reload must neither switch it off by removing
+// every token nor appear to switch it on by adding the first. Tokens are
+// taken from cfg whenever auth stays on either way.
+func keepStartupSettings(old, cfg *config.Config) *config.Config {
+	kept := *cfg
+	o, n := old.Server, &kept.Server
+	n.Transport, n.Addr = o.Transport, o.Addr
+	n.Audit, n.Tracing = o.Audit, o.Tracing
+	n.Auth.OAuth = o.Auth.OAuth
+	if o.Auth.Enabled() != n.Auth.Enabled() {
+		n.Auth.Tokens = o.Auth.Tokens
+	}
+	return &kept
+}
+
+// withdrawn returns the names in before that are missing from after.
+func withdrawn(before, after map[string]bool) []string {
+	var out []string
+	for name := range before {
+		if !after[name] {
+			out = append(out, name)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// restartRequired names the settings that differ between old and cfg but
+// only take effect at startup: the listener, whether and how the HTTP
+// transport authenticates, and the audit and tracing sinks. Static tokens
+// themselves are re-read on every request.
+func restartRequired(old, cfg *config.Config) []string {
+	var out []string
+	o, n := old.Server, cfg.Server
+	if o.Transport != n.Transport {
+		out = append(out, "server.transport")
+	}
+	if o.Addr != n.Addr {
+		out = append(out, "server.addr")
+	}
+	if o.Auth.Enabled() != n.Auth.Enabled() {
+		out = append(out, "server.auth")
+	}
+	if !reflect.DeepEqual(o.Auth.OAuth, n.Auth.OAuth) {
+		out = append(out, "server.auth.oauth")
+	}
+	if o.Audit != n.Audit {
+		out = append(out, "server.audit")
+	}
+	if !reflect.DeepEqual(o.Tracing, n.Tracing) {
+		out = append(out, "server.tracing")
+	}
+	return out
+}
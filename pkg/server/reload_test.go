@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Adding an instance must reach a session that is already connected, and
+// tell it to fetch the tool list again.
+func TestReloadAdvertisesNewlyConfiguredServices(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	s := New(cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull), logger.New("error", "test"))
+	changed := make(chan struct{}, 1)
+	cs := connectServer(t, s, &mcp.ClientOptions{
+		ToolListChangedHandler: func(context.Context, *mcp.ToolListChangedRequest) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		},
+	})
+	if has(toolNames(t, cs), "radarr_list_movies") {
+		t.Fatal("radarr tools advertised before radarr was configured")
+	}
+
+	if restart := s.Reload(mediaCfg(srv.URL)); len(restart) != 0 {
+		t.Errorf("restart required for %v, want nothing", restart)
+	}
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no tools/list_changed notification after the reload")
+	}
+	if names := toolNames(t, cs); !has(names, "radarr_list_movies") || !has(names, "sonarr_list_series") {
+		t.Errorf("tools after reload = %v, want sonarr and radarr", names)
+	}
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "radarr_list_movies"})
+	if err != nil || res.IsError {
+		t.Fatalf("radarr_list_movies on the same session: %v %s", err, contentText(res))
+	}
+}
+
+func TestReloadWithdrawsToolsNoLongerPermitted(t *testing.T) {
+	srv, hits := fakeArr(t, `[]`)
+	s := New(mediaCfg(srv.URL), logger.New("error", "test"))
+	cs := connectServer(t, s, nil)
+
+	readonly := mediaCfg(srv.URL)
+	readonly.Permissions.Mode = config.ModeReadOnly
+	s.Reload(readonly)
+
+	names := toolNames(t, cs)
+	if has(names, "sonarr_add_series") || has(names, "radarr_delete_tag") {
+		t.Errorf("write tools still advertised after switching to readonly: %v", names)
+	}
+	if !has(names, "sonarr_list_series") {
+		t.Error("read tools withdrawn along with the write tools")
+	}
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_delete_tag",
+		Arguments: map[string]any{"id": 1},
+	})
+	if err == nil && !res.IsError {
+		t.Error("a withdrawn write tool could still be called")
+	}
+	if *hits != 0 {
+		t.Errorf("upstream contacted %d times, want none", *hits)
+	}
+}
+
+func TestReloadDropsRemovedInstancesFromTheSchema(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	s := New(cfgWith(map[string][]config.Instance{
+		"sonarr": {
+			{Name: "main", URL: srv.URL, APIKey: "k", Default: true},
+			{Name: "anime", URL: srv.URL, APIKey: "k"},
+		},
+	}, permsFull), logger.New("error", "test"))
+	cs := connectServer(t, s, nil)
+
+	s.Reload(cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_list_series",
+		Arguments: map[string]any{"instance": "anime"},
+	})
+	if err == nil && !res.IsError {
+		t.Error("a removed instance could still be selected")
+	}
+}
+
+func TestReadyRechecksAfterAReload(t *testing.T) {
+	cfg, hits := readyCfg(t, config.ReadyAll)
+	s := New(cfg, logger.New("error", "test"))
+	srv := httptest.NewServer(s.HTTPHandler())
+	t.Cleanup(srv.Close)
+
+	if code, _ := getReady(t, srv.URL); code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d before the reload, want 503", code)
+	}
+	next, _ := readyCfg(t, config.ReadyAll)
+	next.Services["sonarr"] = next.Services["sonarr"][:1]
+	s.Reload(next)
+
+	if code, rep := getReady(t, srv.URL); code != http.StatusOK || len(rep.Instances) != 1 {
+		t.Errorf("status = %d %+v after removing the down instance, want 200 with one instance", code, rep.Instances)
+	}
+	if *hits != 1 {
+		t.Errorf("old upstream pinged %d times, want 1", *hits)
+	}
+}
+
+func TestRestartRequiredNamesStartupOnlySettings(t *testing.T) {
+	old := cfgWith(nil, permsFull)
+	old.Server.Addr = ":8080"
+	next := cfgWith(nil, config.Permissions{Mode: config.ModeReadOnly})
+	next.Server.Addr = ":9090"
+	next.Server.Tracing.Endpoint = "http://collector:4318"
+
+	if got, want := restartRequired(old, next), []string{"server.addr", "server.tracing"}; !slices.Equal(got, want) {
+		t.Errorf("restart required for %v, want %v", got, want)
+	}
+}
+
+// mcpStatus posts an empty body to /mcp with token and returns the status.
+func mcpStatus(t *testing.T, base, token string) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, base+"/mcp", strings.NewReader(`{}`))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /mcp: %v", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+// Removing every token cannot switch auth off, nor lock out the tokens that
+// were valid, until a restart rebuilds the listener.
+func TestReloadRemovingEveryTokenKeepsAuthOn(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	s := New(authCfg(srv.URL, permsFull, config.Token{Name: "laptop", Token: "s3cret"}), logger.New("error", "test"))
+	httpSrv := httptest.NewServer(s.HTTPHandler())
+	t.Cleanup(httpSrv.Close)
+
+	restart := s.Reload(authCfg(srv.URL, permsFull))
+	if !slices.Contains(restart, "server.auth") {
+		t.Errorf("restart required for %v, want server.auth", restart)
+	}
+	if code := mcpStatus(t, httpSrv.URL, ""); code != http.StatusUnauthorized {
+		t.Errorf("status without a token = %d, want 401", code)
+	}
+	if names := toolNames(t, connectHTTP(t, httpSrv.URL, "s3cret")); !has(names, "sonarr_list_series") {
+		t.Errorf("the startup token lost access: %v", names)
+	}
+}
+
+// Adding the first token to an open server is reported as needing a restart
+// and does not make the running configuration claim auth is on.
+func TestReloadAddingTheFirstTokenNeedsARestart(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	s := New(authCfg(srv.URL, permsFull), logger.New("error", "test"))
+
+	restart := s.Reload(authCfg(srv.URL, permsFull, config.Token{Name: "laptop", Token: "s3cret"}))
+	if !slices.Contains(restart, "server.auth") {
+		t.Errorf("restart required for %v, want server.auth", restart)
+	}
+	if s.config().Server.Auth.Enabled() {
+		t.Error("the running configuration reports auth on for a listener built without it")
+	}
+}
+
+// While auth stays on, the token list itself is reloaded.
+func TestReloadRotatesTokens(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	s := New(authCfg(srv.URL, permsFull, config.Token{Name: "laptop", Token: "old"}), logger.New("error", "test"))
+	httpSrv := httptest.NewServer(s.HTTPHandler())
+	t.Cleanup(httpSrv.Close)
+
+	if restart := s.Reload(authCfg(srv.URL, permsFull, config.Token{Name: "laptop", Token: "new"})); len(restart) != 0 {
+		t.Errorf("restart required for %v, want nothing", restart)
+	}
+	if code := mcpStatus(t, httpSrv.URL, "old"); code != http.StatusUnauthorized {
+		t.Errorf("status with the rotated-out token = %d, want 401", code)
+	}
+	if names := toolNames(t, connectHTTP(t, httpSrv.URL, "new")); !has(names, "sonarr_list_series") {
+		t.Errorf("the new token has no access: %v", names)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// resourceMIMEType is the content type of every resource this server serves.
+const resourceMIMEType = "application/json"
+
+// registerResources exposes read-only views of the library as MCP resources so
+// clients that attach context can pull a record without a tool call. URIs name
+// the instance in the host position, e.g. sonarr://main/series/12.
+func registerResources(s *Server) {
+	for _, svc := range []struct {
+		name string
+		spec arr.ServiceSpec
+	}{
+		{"sonarr", arr.SonarrSpec},
+		{"radarr", arr.RadarrSpec},
+		{"lidarr", arr.LidarrSpec},
+		{"readarr", arr.ReadarrSpec},
+		{"prowlarr", arr.ProwlarrSpec},
+	} {
+		registerResource(s, svc.name, svc.spec, "health",
+			"Health warnings and errors reported by "+svc.name+".",
+			func(ctx context.Context, c *arr.Client) (HealthList, error) {
+				issues, err := arr.ListHealthIssues(ctx, c)
+				return HealthList{Issues: issues, Count: len(issues)}, err
+			})
+		if svc.name == "prowlarr" {
+			continue
+		}
+		registerResource(s, svc.name, svc.spec, "queue",
+			"The most recent items in the "+svc.name+" download queue.",
+			func(ctx context.Context, c *arr.Client) (QueueList, error) {
+				items, err := arr.ListQueue(ctx, c, 0)
+				return QueueList{Items: items, Count: len(items)}, err
+			})
+	}
+
+	registerResource(s, "bazarr", arr.BazarrSpec, "health",
+		"Health issues reported by Bazarr.",
+		func(ctx context.Context, c *arr.Client) (BazarrHealthList, error) {
+			issues, err := arr.BazarrHealth(ctx, c)
+			return BazarrHealthList{Issues: issues, Count: len(issues)}, err
+		})
+
+	registerResource(s, "sonarr", arr.SonarrSpec, "calendar",
+		"Episodes airing in Sonarr's default calendar window.",
+		func(ctx context.Context, c *arr.Client) (EpisodeList, error) {
+			eps, err := arr.SonarrCalendar(ctx, c, "", "")
+			return EpisodeList{Episodes: eps, Count: len(eps)}, err
+		})
+	registerResource(s, "radarr", arr.RadarrSpec, "calendar",
+		"Movies releasing in Radarr's default calendar window.",
+		func(ctx context.Context, c *arr.Client) (MovieList, error) {
+			movies, err := arr.RadarrCalendar(ctx, c, "", "")
+			return MovieList{Movies: movies, Count: len(movies)}, err
+		})
+
+	registerResourceTemplate(s, "sonarr", arr.SonarrSpec, "sonarr_series", "series/{id}",
+		"One series from a Sonarr library, by its internal id.",
+		arr.SonarrGetSeries)
+	registerResourceTemplate(s, "radarr", arr.RadarrSpec, "radarr_movie", "movie/{id}",
+		"One movie from a Radarr library, by its internal id.",
+		arr.RadarrGetMovie)
+	registerResourceTemplate(s, "bazarr", arr.BazarrSpec, "bazarr_series_subtitles", "series/{id}/subtitles",
+		"The subtitles present and missing for each episode of a series Bazarr tracks, by Sonarr series id.",
+		func(ctx context.Context, c *arr.Client, id int) (EpisodeSubtitlesList, error) {
+			eps, err := arr.BazarrListEpisodeSubtitles(ctx, c, id)
+			return EpisodeSubtitlesList{Episodes: eps, Count: len(eps)}, err
+		})
+}
+
+// registerResource adds one fixed view per configured instance of service, so
+// resources/list enumerates exactly what can be read.
+func registerResource[Out any](
+	s *Server, service string, spec arr.ServiceSpec, view, description string,
+	fn func(context.Context, *arr.Client) (Out, error),
+) {
+	if !s.registersForService(service, "", AccessRead) {
+		return
+	}
+	name := service + "_" + view
+	for _, inst := range s.config().Services[service] {
+		uri := service + "://" + inst.Name + "/" + view
+		s.pass.resources[uri] = true
+		s.mcp.AddResource(&mcp.Resource{
+			URI:         uri,
+			Name:        name + "_" + inst.Name,
+			Title:       fmt.Sprintf("%s %s (%s)", service, view, inst.Name),
+			Description: description,
+			MIMEType:    resourceMIMEType,
+		}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+			return readResource(ctx, s, req, service, spec, name, func(ctx context.Context, c *arr.Client, _ *url.URL) (Out, error) {
+				return fn(ctx, c)
+			})
+		})
+	}
+}
+
+// registerResourceTemplate adds a parameterised view of single records. path
+// is relative to the instance and its second segment is the record id, e.g.
+// "series/{id}" or "series/{id}/subtitles".
+func registerResourceTemplate[Out any](
+	s *Server, service string, spec arr.ServiceSpec, name, path, description string,
+	fn func(context.Context, *arr.Client, int) (Out, error),
+) {
+	if !s.registersForService(service, "", AccessRead) {
+		return
+	}
+	template := service + "://{instance}/" + path
+	s.pass.templates[template] = true
+	s.mcp.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: template,
+		Name:        name,
+		Description: description + " Instance is one of: " + strings.Join(s.config().InstanceNames(service), ", ") + ".",
+		MIMEType:    resourceMIMEType,
+	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return readResource(ctx, s, req, service, spec, name, func(ctx context.Context, c *arr.Client, u *url.URL) (Out, error) {
+			var zero Out
+			id, err := resourceID(u)
+			if err != nil {
+				return zero, err
+			}
+			return fn(ctx, c, id)
+		})
+	})
+}
+
+// readResource resolves the instance named in the request URI, applies the
+// same read gating as tools, and serves fn's result as JSON.
+func readResource[Out any](
+	ctx context.Context, s *Server, req *mcp.ReadResourceRequest, service string, spec arr.ServiceSpec, name string,
+	fn func(context.Context, *arr.Client, *url.URL) (Out, error),
+) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != service {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	inst, err := s.config().Resolve(service, u.Host)
+	if err != nil {
+		return nil, err
+	}
+	gate := s.gateFor(inst, req.Extra)
+	// Resources are not tools, so allowTools and denyTools do not apply.
+	gate.Perms, _ = gate.Perms.ForTool("")
+	if err := gate.Authorize(ctx, sessionConfirmer{session: req.Session}, name, AccessRead); err != nil {
+		return nil, err
+	}
+
+	client := s.clients.get(service, spec, inst)
+	out, err := fn(ctx, client, u)
+	if err != nil {
+		return nil, fmt.Errorf("%s (%s instance %q): %w", uri, service, inst.Name, err)
+	}
+	body, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", uri, err)
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{
+		URI:      uri,
+		MIMEType: resourceMIMEType,
+		Text:     string(body),
+	}}}, nil
+}
+
+// resourceID extracts the record id, the second path segment of a template URI.
+func resourceID(u *url.URL) (int, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 {
+		return 0, fmt.Errorf("resource %s does not name a record id", u)
+	}
+	id, err := strconv.Atoi(segments[1])
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("resource %s: %q is not a valid id", u, segments[1])
+	}
+	return id, nil
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestResourceListCoversEachInstanceView(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {
+			{Name: "main", URL: srv.URL, APIKey: "k", Default: true},
+			{Name: "anime", URL: srv.URL, APIKey: "k"},
+		},
+	}, permsFull))
+
+	res, err := cs.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	uris := make([]string, 0, len(res.Resources))
+	for _, r := range res.Resources {
+		uris = append(uris, r.URI)
+	}
+	for _, want := range []string{
+		"sonarr://main/health", "sonarr://main/queue", "sonarr://main/calendar",
+		"sonarr://anime/health", "sonarr://anime/queue", "sonarr://anime/calendar",
+	} {
+		if !has(uris, want) {
+			t.Errorf("resource %s missing from %v", want, uris)
+		}
+	}
+	for _, uri := range uris {
+		if !strings.HasPrefix(uri, "sonarr://") {
+			t.Errorf("resource %s listed for an unconfigured service", uri)
+		}
+	}
+}
+
+func TestResourceTemplatesOnlyForConfiguredServices(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"radarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+		"bazarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.ListResourceTemplates(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListResourceTemplates: %v", err)
+	}
+	var templates []string
+	for _, tmpl := range res.ResourceTemplates {
+		templates = append(templates, tmpl.URITemplate)
+	}
+	for _, want := range []string{"radarr://{instance}/movie/{id}", "bazarr://{instance}/series/{id}/subtitles"} {
+		if !has(templates, want) {
+			t.Errorf("template %s missing from %v", want, templates)
+		}
+	}
+	if has(templates, "sonarr://{instance}/series/{id}") {
+		t.Errorf("sonarr template advertised without a sonarr instance: %v", templates)
+	}
+}
+
+func TestReadSeriesResourceRoutesToTheNamedInstance(t *testing.T) {
+	main, mainPaths := recordingArr(t, `{"id":12,"title":"Main Show"}`)
+	anime, animePaths := recordingArr(t, `{"id":12,"title":"Anime Show"}`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {
+			{Name: "main", URL: main.URL, APIKey: "k", Default: true},
+			{Name: "anime", URL: anime.URL, APIKey: "k"},
+		},
+	}, permsFull))
+
+	res, err := cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "sonarr://anime/series/12"})
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if len(res.Contents) != 1 || !strings.Contains(res.Contents[0].Text, "Anime Show") {
+		t.Fatalf("contents = %+v, want the anime instance's series", res.Contents)
+	}
+	if res.Contents[0].MIMEType != "application/json" {
+		t.Errorf("mimeType = %q, want application/json", res.Contents[0].MIMEType)
+	}
+	if len(*mainPaths) != 0 || len(*animePaths) != 1 || (*animePaths)[0] != "GET /api/v3/series/12" {
+		t.Errorf("main calls = %v, anime calls = %v; want one GET /api/v3/series/12 on anime",
+			*mainPaths, *animePaths)
+	}
+}
+
+func TestReadResourceRejectsUnknownInstance(t *testing.T) {
+	srv, hits := fakeArr(t, `{}`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	_, err := cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "sonarr://typo/series/1"})
+	if err == nil {
+		t.Fatal("expected an unknown instance to be rejected")
+	}
+	if !strings.Contains(err.Error(), "main") {
+		t.Errorf("error %q does not name the valid instances", err)
+	}
+	if *hits != 0 {
+		t.Errorf("upstream contacted %d times for an invalid instance", *hits)
+	}
+}
+
+// Resources are read-only, so readonly mode must still expose them.
+func TestResourcesSurviveReadOnlyMode(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, config.Permissions{Mode: config.ModeReadOnly}))
+
+	res, err := cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "sonarr://main/health"})
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if len(res.Contents) != 1 || !strings.Contains(res.Contents[0].Text, `"count":0`) {
+		t.Errorf("contents = %+v, want an empty health list", res.Contents)
+	}
+}
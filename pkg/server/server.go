@@ -5,50 +5,96 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/GauranshMathur/ARR_MCP/pkg/audit"
 	"github.com/GauranshMathur/ARR_MCP/pkg/config"
 	"github.com/GauranshMathur/ARR_MCP/pkg/logger"
+	"github.com/GauranshMathur/ARR_MCP/pkg/metrics"
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
 )
 
 // Server wires configured service instances into an MCP server.
 type Server struct {
-	cfg *config.Config
+	// cfg is the running configuration. Reload swaps it, so handlers load it
+	// once per call and use that snapshot throughout.
+	cfg atomic.Pointer[config.Config]
 	log *logger.Logger
 	mcp *mcp.Server
+	// oauth validates JWT access tokens when server.auth.oauth is set.
+	oauth *jwtVerifier
+	// advertised is what the last registration pass registered, so prompts
+	// only reference tools this deployment actually advertises and a reload
+	// knows what to withdraw.
+	advertised atomic.Pointer[catalog]
+	// pass collects registrations while registerAll runs. Only the goroutine
+	// holding reloadMu, or New, touches it.
+	pass     *catalog
+	reloadMu sync.Mutex
+	// auditLog receives mutating tool calls; nil disables auditing.
+	auditLog *audit.Log
+	// clients holds one pooled client per configured instance.
+	clients clientPool
+	// confirmKey signs the state of confirmation prompts.
+	confirmKey []byte
 }
 
-// New builds a server exposing tools for every configured service instance.
+// New builds a server exposing tools for every configured service instance,
+// plus prompts for the workflows those tools support.
 func New(cfg *config.Config, log *logger.Logger) *Server {
 	s := &Server{
-		cfg: cfg,
-		log: log,
-		mcp: mcp.NewServer(&mcp.Implementation{Name: "arr-mcp", Version: Version}, nil),
+		log:        log,
+		mcp:        mcp.NewServer(&mcp.Implementation{Name: "arr-mcp", Version: Version}, nil),
+		clients:    clientPool{policy: cfg.Server.Upstream},
+		confirmKey: newConfirmKey(),
 	}
-	registerAll(s)
+	s.cfg.Store(cfg)
+	if o := cfg.Server.Auth.OAuth; o != nil {
+		s.oauth = newJWTVerifier(*o)
+	}
+	s.advertise()
 	return s
 }
 
+// config returns the running configuration.
+func (s *Server) config() *config.Config { return s.cfg.Load() }
+
 // MCP returns the underlying protocol server, for transports and tests.
 func (s *Server) MCP() *mcp.Server { return s.mcp }
 
-// gateFor returns the permission gate governing a specific instance, honouring
-// any per-instance override of the global policy.
-func (s *Server) gateFor(inst *config.Instance) Gate {
-	return Gate{Perms: s.cfg.EffectivePermissions(inst)}
+// gateFor returns the permission gate governing a call to a specific instance,
+// honouring any per-instance override of the global policy, any override
+// carried by the caller's static token, and the caller's OAuth scopes. A nil
+// extra describes no caller, as at registration time.
+func (s *Server) gateFor(inst *config.Instance, extra *mcp.RequestExtra) Gate {
+	return Gate{
+		Perms:  s.config().CallerPermissions(inst, s.callerToken(extra)),
+		Scopes: callerScopes(extra),
+	}
 }
 
-// registersForService reports whether any configured instance of a service
-// permits tools at this access tier. A single unrestricted instance is enough
-// to justify advertising the tool; per-instance policy is enforced at call time.
-func (s *Server) registersForService(service string, a Access) bool {
-	instances := s.cfg.Services[service]
+// registersForService reports whether any configured instance of a service, or
+// any bearer token, permits tool at this access tier. tool is empty for
+// resources and prompts, which the tool rules do not cover. A single unrestricted
+// instance or token is enough to justify advertising the tool; the policy for
+// the actual caller is enforced at call time.
+func (s *Server) registersForService(service, tool string, a Access) bool {
+	cfg := s.config()
+	instances := cfg.Services[service]
 	if len(instances) == 0 {
 		return false
 	}
 	for i := range instances {
-		if s.gateFor(&instances[i]).Registers(a) {
+		if s.gateFor(&instances[i], nil).Registers(tool, a) {
+			return true
+		}
+	}
+	for _, tok := range cfg.Server.Auth.Tokens {
+		if tok.Permissions != nil && (Gate{Perms: *tok.Permissions}).Registers(tool, a) {
 			return true
 		}
 	}
@@ -62,19 +108,60 @@ func (s *Server) RunStdio(ctx context.Context) error {
 	return s.mcp.Run(ctx, &mcp.StdioTransport{})
 }
 
-// RunHTTP serves MCP over Streamable HTTP at /mcp, plus a /health probe.
-func (s *Server) RunHTTP(ctx context.Context, addr string) error {
+// HTTPHandler serves MCP at /mcp, behind bearer authentication when tokens or
+// OAuth are configured, plus always-open /health and /ready probes, Prometheus
+// metrics at /metrics and, under OAuth, the protected resource metadata clients
+// use to find the issuer.
+func (s *Server) HTTPHandler() http.Handler {
+	var endpoint http.Handler = mcp.NewStreamableHTTPHandler(
+		func(*http.Request) *mcp.Server { return s.mcp }, nil)
+	cfg := s.config()
+	if cfg.Server.Auth.Enabled() {
+		endpoint = s.requireAuth()(endpoint)
+	}
+
 	mux := http.NewServeMux()
-	mux.Handle("/mcp", mcp.NewStreamableHTTPHandler(
-		func(*http.Request) *mcp.Server { return s.mcp }, nil))
+	mux.Handle("/mcp", endpoint)
+	if o := cfg.Server.Auth.OAuth; o != nil {
+		mux.Handle(protectedResourcePath, auth.ProtectedResourceMetadataHandler(&oauthex.ProtectedResourceMetadata{
+			Resource:               o.Audience,
+			AuthorizationServers:   []string{o.Issuer},
+			ScopesSupported:        []string{ScopeRead, ScopeWrite, ScopeDestructive},
+			BearerMethodsSupported: []string{"header"},
+			ResourceName:           "arr-mcp",
+		}))
+	}
+	mux.Handle("/metrics", metrics.Default.Handler(func() {
+		n := 0
+		for range s.mcp.Sessions() {
+			n++
+		}
+		metrics.ActiveSessions.Set(float64(n))
+	}))
+	mux.Handle("/ready", &readiness{cfg: s.config, log: s.log})
 	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
+	return mux
+}
+
+// RunHTTP serves HTTPHandler on addr until ctx is cancelled.
+func (s *Server) RunHTTP(ctx context.Context, addr string) error {
+	if !s.config().Server.Auth.Enabled() {
+		s.log.Warn("no server.auth tokens configured: anyone who can reach %s can call every tool", addr)
+	}
+	// Load the key set up front so a bad jwks setting fails at startup, not
+	// on the first client request.
+	if s.oauth != nil {
+		if err := s.oauth.refresh(ctx); err != nil {
+			return err
+		}
+	}
 
 	httpServer := &http.Server{
 		Addr:              addr,
-		Handler:           mux,
+		Handler:           s.HTTPHandler(),
 		ReadHeaderTimeout: 10 * time.Second,
 		IdleTimeout:       120 * time.Second,
 	}
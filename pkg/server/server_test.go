@@ -28,15 +28,26 @@ func fakeArr(t *testing.T, body string) (*httptest.Server, *int) {
 
 // connect wires an in-memory MCP client to the server under test.
 func connect(t *testing.T, cfg *config.Config) *mcp.ClientSession {
+	t.Helper()
+	return connectWith(t, cfg, nil)
+}
+
+// connectWith is connect for tests that need client-side handlers.
+func connectWith(t *testing.T, cfg *config.Config, opts *mcp.ClientOptions) *mcp.ClientSession {
+	t.Helper()
+	return connectServer(t, New(cfg, logger.New("error", "test")), opts)
+}
+
+// connectServer is connectWith for tests that configure the Server first.
+func connectServer(t *testing.T, s *Server, opts *mcp.ClientOptions) *mcp.ClientSession {
 	t.Helper()
 	ctx := context.Background()
 
-	s := New(cfg, logger.New("error", "test"))
 	ct, st := mcp.NewInMemoryTransports()
 	if _, err := s.MCP().Connect(ctx, st, nil); err != nil {
 		t.Fatalf("server connect: %v", err)
 	}
-	cs, err := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v0"}, nil).Connect(ctx, ct, nil)
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v0"}, opts).Connect(ctx, ct, nil)
 	if err != nil {
 		t.Fatalf("client connect: %v", err)
 	}
@@ -645,3 +656,168 @@ func TestTriggerSearchToolPostsACommand(t *testing.T) {
 		t.Errorf("upstream calls = %v, want one POST /api/v3/command", *paths)
 	}
 }
+
+func TestReleaseToolsAreRegisteredForBothMediaServices(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+		"radarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, config.Permissions{Mode: config.ModeReadOnly, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny}))
+
+	names := toolNames(t, cs)
+	for _, want := range []string{"sonarr_list_releases", "radarr_list_releases"} {
+		if !has(names, want) {
+			t.Errorf("readonly mode must still expose %s", want)
+		}
+	}
+	for _, unwanted := range []string{"sonarr_grab_release", "radarr_grab_release"} {
+		if has(names, unwanted) {
+			t.Errorf("readonly mode must not expose %s", unwanted)
+		}
+	}
+}
+
+func TestGrabReleaseToolPostsTheChosenRelease(t *testing.T) {
+	srv, paths := recordingArr(t, `{"guid":"abc","indexerId":2,"title":"Movie.2024.1080p"}`)
+	cs := connect(t, mediaCfg(srv.URL))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "radarr_grab_release",
+		Arguments: map[string]any{"guid": "abc", "indexerId": 2},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+	if len(*paths) != 1 || (*paths)[0] != "POST /api/v3/release" {
+		t.Errorf("upstream calls = %v, want one POST /api/v3/release", *paths)
+	}
+}
+
+func TestManualImportToolsSitWithTheQueueTools(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	names := toolNames(t, connect(t, cfgWith(map[string][]config.Instance{
+		"sonarr":   {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+		"prowlarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull)))
+
+	for _, want := range []string{"sonarr_manual_import_candidates", "sonarr_manual_import"} {
+		if !has(names, want) {
+			t.Errorf("tool %q not advertised", want)
+		}
+	}
+	if has(names, "prowlarr_manual_import") {
+		t.Error("prowlarr has no download queue and must not offer manual import")
+	}
+}
+
+func TestManualImportIsDeniedWhenClientCannotPrompt(t *testing.T) {
+	srv, hits := fakeArr(t, `[]`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"radarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, config.Permissions{Mode: config.ModeConfirm, ConfirmScope: config.ScopeWrite, Fallback: config.FallbackDeny}))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "radarr_manual_import",
+		Arguments: map[string]any{
+			"downloadId": "ABC", "files": []any{map[string]any{"path": "/dl/movie.mkv", "movieId": 3}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CallTool transport error: %v", err)
+	}
+	if !res.IsError {
+		t.Fatal("expected the import to be denied without elicitation support")
+	}
+	if *hits != 0 {
+		t.Errorf("upstream was contacted %d times despite denial", *hits)
+	}
+}
+
+// Lidarr gets its own library tools plus the same shared operations and media
+// tools as Sonarr and Radarr.
+func TestLidarrToolsRegisterWithSharedOperations(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	names := toolNames(t, connect(t, cfgWith(map[string][]config.Instance{
+		"lidarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull)))
+
+	for _, want := range []string{
+		"lidarr_list_artists", "lidarr_list_albums", "lidarr_search_artists", "lidarr_add_artist",
+		"lidarr_edit_artists", "lidarr_delete_artist", "lidarr_wanted_missing",
+		"lidarr_health", "lidarr_queue", "lidarr_history", "lidarr_run_command",
+		"lidarr_list_tags", "lidarr_create_tag",
+	} {
+		if !has(names, want) {
+			t.Errorf("tool %q not advertised", want)
+		}
+	}
+	if has(names, "sonarr_list_series") {
+		t.Error("sonarr tools exposed without a configured sonarr instance")
+	}
+}
+
+func TestLidarrQueueToolUsesTheV1API(t *testing.T) {
+	srv, paths := recordingArr(t, `{"records":[]}`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"lidarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "lidarr_queue"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+	if len(*paths) != 1 || (*paths)[0] != "GET /api/v1/queue" {
+		t.Errorf("upstream calls = %v, want one GET /api/v1/queue", *paths)
+	}
+}
+
+func TestReadarrToolsRegisterWithSharedOperations(t *testing.T) {
+	srv, _ := fakeArr(t, `[]`)
+	names := toolNames(t, connect(t, cfgWith(map[string][]config.Instance{
+		"readarr": {
+			{Name: "ebooks", URL: srv.URL, APIKey: "k", Default: true},
+			{Name: "audiobooks", URL: srv.URL, APIKey: "k"},
+		},
+	}, permsFull)))
+
+	for _, want := range []string{
+		"readarr_list_authors", "readarr_search_authors", "readarr_list_books", "readarr_search_books",
+		"readarr_add_author", "readarr_monitor_books", "readarr_wanted_missing",
+		"readarr_health", "readarr_queue", "readarr_history", "readarr_disk_space",
+		"readarr_list_tags", "readarr_create_tag",
+	} {
+		if !has(names, want) {
+			t.Errorf("tool %q not advertised", want)
+		}
+	}
+	if has(names, "lidarr_list_artists") {
+		t.Error("lidarr tools exposed without a configured lidarr instance")
+	}
+}
+
+func TestReadarrWantedMissingUsesTheV1API(t *testing.T) {
+	srv, paths := recordingArr(t, `{"totalRecords":4,"records":[{"id":2,"authorId":1,"title":"Dune"}]}`)
+	cs := connect(t, cfgWith(map[string][]config.Instance{
+		"readarr": {{Name: "main", URL: srv.URL, APIKey: "k", Default: true}},
+	}, permsFull))
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "readarr_wanted_missing"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("tool returned an error: %s", contentText(res))
+	}
+	if len(*paths) != 1 || (*paths)[0] != "GET /api/v1/wanted/missing" {
+		t.Errorf("upstream calls = %v, want one GET /api/v1/wanted/missing", *paths)
+	}
+	if text := contentText(res); !strings.Contains(text, `"total":4`) {
+		t.Errorf("result = %s, want the library-wide total", text)
+	}
+}
@@ -2,9 +2,16 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/GauranshMathur/ARR_MCP/pkg/arr"
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/metrics"
+	"github.com/GauranshMathur/ARR_MCP/pkg/tracing"
 	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -18,23 +25,58 @@ type InstanceArg struct {
 // instanceName reports the selected instance, if any.
 func (a InstanceArg) instanceName() string { return a.Instance }
 
+// WaitArg is embedded in the input of tools that start a background command,
+// letting the caller block until it finishes instead of polling.
+type WaitArg struct {
+	Wait int `json:"wait,omitempty" jsonschema:"seconds to wait for the command to finish, at most 300; omit to return as soon as it is queued"`
+}
+
+// duration reports how long to wait, capped at maxCommandWait.
+func (a WaitArg) duration() time.Duration {
+	return min(time.Duration(a.Wait)*time.Second, maxCommandWait)
+}
+
 // instanceSelector is satisfied by any tool input embedding InstanceArg.
 type instanceSelector interface{ instanceName() string }
 
+// instanceChecked is satisfied by tool inputs with arguments the target
+// instance's configuration restricts. check runs before the permission gate,
+// so a refused argument neither prompts the user nor reaches the service.
+type instanceChecked interface {
+	check(cfg *config.Config, service string, spec arr.ServiceSpec, inst *config.Instance) error
+}
+
+// ruledArgument is satisfied by tool inputs with an argument the permission
+// tool rules can name, as in sonarr_run_command:RssSync.
+type ruledArgument interface{ ruleArgument() string }
+
+// recordIdentified is satisfied by tool inputs naming one record by id, which
+// a destructive tool's confirmation form asks the user to recognise.
+type recordIdentified interface{ recordID() int }
+
 // toolMeta describes a tool independently of its handler.
 type toolMeta struct {
 	name        string
 	description string
 	access      Access
+	// enums lists the values the input schema allows for arguments beyond
+	// instance, by property name.
+	enums map[string][]string
+	// title looks up the title of the record a destructive tool acts on, for
+	// the user to type when confirming it.
+	title func(ctx context.Context, c *arr.Client, id int) (string, error)
 }
 
 // register adds one tool, wiring instance resolution, permission gating and
-// client construction around the service call in fn.
+// client construction around the service call in fn. Write and destructive
+// tools also take dryRun, under which fn runs with its mutations held back
+// and the tool returns a DryRun instead of Out.
 func register[In instanceSelector, Out any](
 	s *Server, service string, spec arr.ServiceSpec, meta toolMeta,
 	fn func(context.Context, *arr.Client, In) (Out, error),
 ) {
-	if !s.registersForService(service, meta.access) {
+	s.pass.known = append(s.pass.known, meta.name)
+	if !s.registersForService(service, meta.name, meta.access) {
 		return
 	}
 
@@ -46,37 +88,201 @@ func register[In instanceSelector, Out any](
 	// Advertise the configured instance names so the model picks from a
 	// closed set instead of guessing.
 	if prop, ok := schema.Properties["instance"]; ok {
-		names := s.cfg.InstanceNames(service)
+		names := s.config().InstanceNames(service)
 		enum := make([]any, 0, len(names))
 		for _, n := range names {
 			enum = append(enum, n)
 		}
 		prop.Enum = enum
 	}
+	for name, values := range meta.enums {
+		if prop, ok := schema.Properties[name]; ok {
+			prop.Enum = make([]any, 0, len(values))
+			for _, v := range values {
+				prop.Enum = append(prop.Enum, v)
+			}
+		}
+	}
 
-	mcp.AddTool(s.mcp, &mcp.Tool{
-		Name:        meta.name,
-		Description: meta.description,
-		Annotations: meta.access.Annotations(),
-		InputSchema: schema,
-	}, func(ctx context.Context, req *mcp.CallToolRequest, in In) (*mcp.CallToolResult, Out, error) {
+	// Mutating tools take dryRun on top of their own arguments.
+	mutating := meta.access != AccessRead
+	if mutating {
+		schema.Properties["dryRun"] = &jsonschema.Schema{
+			Type:        "boolean",
+			Description: "validate and authorize the call, then report the request it would send without sending it",
+		}
+	}
+
+	call := func(ctx context.Context, req *mcp.CallToolRequest, in In) (Out, *DryRun, error) {
 		var zero Out
 
-		inst, err := s.cfg.Resolve(service, in.instanceName())
+		cfg := s.config()
+		inst, err := cfg.Resolve(service, in.instanceName())
+		if err != nil {
+			return zero, nil, err
+		}
+		if c, ok := any(in).(instanceChecked); ok {
+			if err := c.check(cfg, service, spec, inst); err != nil {
+				return zero, nil, err
+			}
+		}
+		ctx = tracing.WithAttrs(ctx, tracing.String("arr.instance", inst.Name))
+		ctx, span := tracing.Start(ctx, "tools/call "+meta.name, tracing.KindServer,
+			tracing.String("mcp.tool", meta.name),
+			tracing.String("arr.service", service),
+			tracing.String("arr.access", meta.access.String()))
+		start := time.Now()
+		ruled := ruledName(meta.name, in)
+		confirmer := sessionConfirmer{
+			session:   req.Session,
+			responses: req.Params.InputResponses,
+			state:     req.Params.RequestState,
+			key:       s.confirmKey,
+			binding:   confirmBinding(meta.name, service, inst.Name, req.Params.Arguments),
+		}
+		if mutating {
+			if confirmer.form, err = newConfirmForm(service, inst.Name, meta.access, schema, in); err != nil {
+				span.End(err)
+				return zero, nil, err
+			}
+			if id, ok := any(in).(recordIdentified); ok && meta.title != nil {
+				client := s.clients.get(service, spec, inst)
+				confirmer.form.title = func(ctx context.Context) (string, error) {
+					return meta.title(ctx, client, id.recordID())
+				}
+			}
+		}
+		decision, err := s.gateFor(inst, req.Extra).Decide(ctx, confirmer, ruled, meta.access)
+		if err == nil {
+			in, err = confirmEdits(cfg, service, spec, inst, ruled, confirmer.form, in)
+		}
+		if decision == OutcomePending {
+			// The call is decided, and recorded, when the client returns
+			// with the user's answer.
+			span.End(nil)
+			return zero, nil, err
+		}
 		if err != nil {
-			return nil, zero, err
+			span.End(err)
+			metrics.ToolCalls.Since(start, meta.name, inst.Name, string(decision))
+			s.recordAudit(req, service, inst, meta, in, decision, 0, err, start)
+			return zero, nil, err
+		}
+
+		var plan *arr.Plan
+		if mutating && (cfg.Server.DryRun || dryRunRequested(req.Params.Arguments)) {
+			ctx, plan = arr.DryRun(ctx)
+		}
+		ctx, upstreamStatus := arr.RecordStatus(ctx)
+		out, err := fn(withProgress(ctx, req), s.clients.get(service, spec, inst), in)
+		if plan != nil && (err == nil || len(plan.Requests()) > 0) {
+			// The plan is the result, even an empty one: the caller asked
+			// for a dry run and must be able to tell that is what ran. Only
+			// a call that failed before planning anything reports its error.
+			err = arr.ErrDryRun
 		}
-		if err := s.gateFor(inst).Authorize(ctx, sessionConfirmer{req.Session}, meta.name, meta.access); err != nil {
-			return nil, zero, err
+		span.End(ignoreDryRun(err))
+		metrics.ToolCalls.Since(start, meta.name, inst.Name, callOutcome(err))
+		s.recordAudit(req, service, inst, meta, in, decision, upstreamStatus(), err, start)
+		if errors.Is(err, arr.ErrDryRun) {
+			return zero, newDryRun(meta.name, inst.Name, plan), nil
 		}
+		if err != nil {
+			return zero, nil, fmt.Errorf("%s (%s instance %q): %w", meta.name, service, inst.Name, err)
+		}
+		return out, nil, nil
+	}
+
+	tool := &mcp.Tool{
+		Name:        meta.name,
+		Description: meta.description,
+		Annotations: meta.access.Annotations(),
+		InputSchema: schema,
+	}
+	if !mutating {
+		mcp.AddTool(s.mcp, tool, func(ctx context.Context, req *mcp.CallToolRequest, in In) (*mcp.CallToolResult, Out, error) {
+			out, _, err := call(ctx, req, in)
+			return nil, out, err
+		})
+		s.pass.tools[meta.name] = true
+		return
+	}
 
-		client := arr.NewClient(inst.URL, spec, arr.Credentials{APIKey: inst.APIKey})
-		out, err := fn(ctx, client, in)
+	// A mutating tool answers with its usual output or, under a dry run, the
+	// plan, so its output schema admits either.
+	output, err := dryRunOutputSchema[Out]()
+	if err != nil {
+		s.log.Error("building output schema for %s: %v", meta.name, err)
+		return
+	}
+	tool.OutputSchema = output
+	mcp.AddTool(s.mcp, tool, func(ctx context.Context, req *mcp.CallToolRequest, in In) (*mcp.CallToolResult, any, error) {
+		out, plan, err := call(ctx, req, in)
+		var pending *confirmPending
+		if errors.As(err, &pending) {
+			return pending.result(), nil, nil
+		}
 		if err != nil {
-			return nil, zero, fmt.Errorf("%s (%s instance %q): %w", meta.name, service, inst.Name, err)
+			return nil, nil, err
+		}
+		if plan != nil {
+			return nil, plan, nil
 		}
 		return nil, out, nil
 	})
+	s.pass.tools[meta.name] = true
+}
+
+// ruledName is the name the permission tool rules judge a call by: the tool,
+// qualified by its ruled argument if it has one.
+func ruledName(tool string, in any) string {
+	if arg, ok := in.(ruledArgument); ok {
+		return tool + ":" + strings.TrimSpace(arg.ruleArgument())
+	}
+	return tool
+}
+
+// confirmEdits returns in with the values the user edited in the
+// confirmation form, checked again against the instance. An edit to the
+// argument the tool rules judge by, or to the record a destructive call was
+// confirmed by typing the title of, is refused rather than re-authorized,
+// since the user approved the call under the old one.
+func confirmEdits[In any](
+	cfg *config.Config, service string, spec arr.ServiceSpec, inst *config.Instance,
+	ruled string, form *confirmForm, in In,
+) (In, error) {
+	edited, changed, err := applyEdits(in, form)
+	if err != nil || !changed {
+		return in, err
+	}
+	if c, ok := any(edited).(instanceChecked); ok {
+		if err := c.check(cfg, service, spec, inst); err != nil {
+			return in, err
+		}
+	}
+	tool, _, _ := strings.Cut(ruled, ":")
+	if now := ruledName(tool, edited); now != ruled {
+		return in, fmt.Errorf("the confirmation form changed %s to %s, which the permission policy "+
+			"decides on; call the tool again with the new value", ruled, now)
+	}
+	if was, ok := any(in).(recordIdentified); ok {
+		if now := any(edited).(recordIdentified); now.recordID() != was.recordID() {
+			return in, fmt.Errorf("the confirmation form changed the record from id %d to %d, which was "+
+				"not the one confirmed; call the tool again with the new id", was.recordID(), now.recordID())
+		}
+	}
+	return edited, nil
+}
+
+// callOutcome labels a completed tool call for metrics.
+func callOutcome(err error) string {
+	switch {
+	case errors.Is(err, arr.ErrDryRun):
+		return "dry_run"
+	case err != nil:
+		return "error"
+	}
+	return "ok"
 }
 
 // --- tool input types ---
@@ -84,6 +290,20 @@ func register[In instanceSelector, Out any](
 // EmptyArgs is the input for tools that only need an instance.
 type EmptyArgs struct{ InstanceArg }
 
+// ListArgs is the input for library listings served from the response cache.
+type ListArgs struct {
+	InstanceArg
+	Fresh bool `json:"fresh,omitempty" jsonschema:"bypass the cache of recent results, e.g. right after a change made outside this server"`
+}
+
+// context applies the cache bypass, when requested, to ctx.
+func (a ListArgs) context(ctx context.Context) context.Context {
+	if a.Fresh {
+		return arr.NoCache(ctx)
+	}
+	return ctx
+}
+
 // SearchArgs is the input for title lookup tools.
 type SearchArgs struct {
 	InstanceArg
@@ -117,6 +337,9 @@ type DeleteArgs struct {
 	DeleteFiles bool `json:"deleteFiles,omitempty" jsonschema:"also delete downloaded files from disk"`
 }
 
+// recordID names the record to delete in the confirmation form.
+func (a DeleteArgs) recordID() int { return a.ID }
+
 // ProwlarrSearchArgs is the input for prowlarr_search.
 type ProwlarrSearchArgs struct {
 	InstanceArg
@@ -185,10 +408,52 @@ type DeleteQueueArgs struct {
 	Blocklist        bool `json:"blocklist,omitempty" jsonschema:"blocklist the release so it is not grabbed again"`
 }
 
+// ImportScopeArgs is the input for the manual_import_candidates tools.
+type ImportScopeArgs struct {
+	InstanceArg
+	DownloadID string `json:"downloadId,omitempty" jsonschema:"downloadId of a stuck item from the queue tool"`
+	Folder     string `json:"folder,omitempty" jsonschema:"folder to scan instead, as seen by the service"`
+}
+
+// ManualImportArgs is the input for the manual_import tools.
+type ManualImportArgs struct {
+	InstanceArg
+	WaitArg
+	DownloadID string           `json:"downloadId,omitempty" jsonschema:"the downloadId the candidates were listed for"`
+	Folder     string           `json:"folder,omitempty" jsonschema:"the folder the candidates were listed for"`
+	ImportMode string           `json:"importMode,omitempty" jsonschema:"auto, move or copy; defaults to auto"`
+	Files      []arr.ImportFile `json:"files" jsonschema:"files to import, each with the media it should be imported as"`
+}
+
 // CommandArgs is the input for triggering a background command.
 type CommandArgs struct {
 	InstanceArg
-	Name string `json:"name" jsonschema:"command name, e.g. RefreshSeries, RssSync, Backup"`
+	WaitArg
+	Name string `json:"name" jsonschema:"command to run, one of those this instance allows"`
+}
+
+// check refuses commands outside the instance's allowlist.
+func (a CommandArgs) check(cfg *config.Config, service string, spec arr.ServiceSpec, inst *config.Instance) error {
+	allowed := cfg.AllowedCommands(service, inst, spec.Commands)
+	// Configured names are in the service's casing, as the schema offers them.
+	if slices.Contains(allowed, a.Name) {
+		return nil
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("command %q refused: %s instance %q allows no commands", a.Name, service, inst.Name)
+	}
+	return fmt.Errorf("command %q is not allowed on %s instance %q; allowed: %s",
+		a.Name, service, inst.Name, strings.Join(allowed, ", "))
+}
+
+// ruleArgument lets tool rules allow or deny individual commands.
+func (a CommandArgs) ruleArgument() string { return a.Name }
+
+// CommandStatusArgs is the input for the command_status tools.
+type CommandStatusArgs struct {
+	InstanceArg
+	WaitArg
+	ID int `json:"id" jsonschema:"command id returned by the tool that started it"`
 }
 
 // CalendarArgs is the input for calendar tools.
@@ -198,6 +463,13 @@ type CalendarArgs struct {
 	End   string `json:"end,omitempty" jsonschema:"end date as YYYY-MM-DD"`
 }
 
+// RefreshSeriesArgs is the input for sonarr_refresh_series.
+type RefreshSeriesArgs struct {
+	InstanceArg
+	WaitArg
+	SeriesID int `json:"seriesId" jsonschema:"series id from sonarr_list_series"`
+}
+
 // EpisodesArgs is the input for listing a series' episodes.
 type EpisodesArgs struct {
 	InstanceArg
@@ -223,6 +495,20 @@ type QueueList struct {
 	Count int             `json:"count"`
 }
 
+// CommandOutcome is a command's state as returned by the tools that start or
+// check one. StillRunning is set when a wait hit its deadline first; the
+// command carries on regardless, and command_status can pick it up again.
+type CommandOutcome struct {
+	arr.CommandResult
+	StillRunning bool `json:"stillRunning,omitempty" jsonschema:"the wait ended before the command did"`
+}
+
+// ImportCandidateList wraps manual import scan results.
+type ImportCandidateList struct {
+	Candidates []arr.ImportCandidate `json:"candidates"`
+	Count      int                   `json:"count"`
+}
+
 // HistoryList wraps history results.
 type HistoryList struct {
 	Records []arr.HistoryRecord `json:"records"`
@@ -382,6 +668,9 @@ type IDArgs struct {
 	ID int `json:"id" jsonschema:"internal id of the record"`
 }
 
+// recordID names the record in a destructive tool's confirmation form.
+func (a IDArgs) recordID() int { return a.ID }
+
 // --- media service tool outputs ---
 
 // TagList wraps tag results.
@@ -518,6 +807,7 @@ type DeletedCount struct {
 // SearchScopeArgs is the input for sonarr_trigger_search.
 type SearchScopeArgs struct {
 	InstanceArg
+	WaitArg
 	SeriesID     int   `json:"seriesId" jsonschema:"series id from sonarr_list_series"`
 	SeasonNumber *int  `json:"seasonNumber,omitempty" jsonschema:"search one season only"`
 	EpisodeIDs   []int `json:"episodeIds,omitempty" jsonschema:"search specific episodes only; overrides seriesId and seasonNumber"`
@@ -526,9 +816,41 @@ type SearchScopeArgs struct {
 // MovieIDsArgs is the input for tools acting on several movies.
 type MovieIDsArgs struct {
 	InstanceArg
+	WaitArg
 	MovieIDs []int `json:"movieIds" jsonschema:"movie ids from radarr_list_movies"`
 }
 
+// EpisodeReleasesArgs is the input for sonarr_list_releases.
+type EpisodeReleasesArgs struct {
+	InstanceArg
+	EpisodeID    int  `json:"episodeId,omitempty" jsonschema:"episode id from sonarr_list_episodes"`
+	SeriesID     int  `json:"seriesId,omitempty" jsonschema:"series id from sonarr_list_series; use with seasonNumber"`
+	SeasonNumber *int `json:"seasonNumber,omitempty" jsonschema:"search a whole season, including season packs"`
+	Limit        int  `json:"limit,omitempty" jsonschema:"maximum releases to return; defaults to 20"`
+}
+
+// MovieReleasesArgs is the input for radarr_list_releases.
+type MovieReleasesArgs struct {
+	InstanceArg
+	MovieID int `json:"movieId" jsonschema:"movie id from radarr_list_movies"`
+	Limit   int `json:"limit,omitempty" jsonschema:"maximum releases to return; defaults to 20"`
+}
+
+// GrabReleaseArgs is the input for the grab_release tools.
+type GrabReleaseArgs struct {
+	InstanceArg
+	GUID      string `json:"guid" jsonschema:"guid of a release from list_releases"`
+	IndexerID int    `json:"indexerId" jsonschema:"indexerId of the same release"`
+}
+
+// CandidateList wraps interactive search results. Unlike ReleaseList these
+// carry the service's verdict on each release, not just the indexer's data.
+type CandidateList struct {
+	Releases []arr.Release `json:"releases"`
+	Count    int           `json:"count"`
+	Total    int           `json:"total" jsonschema:"releases found before the limit was applied"`
+}
+
 // BlocklistList wraps blocklist results.
 type BlocklistList struct {
 	Items []arr.BlocklistItem `json:"items"`
@@ -553,3 +875,129 @@ type CollectionList struct {
 	Collections []arr.Collection `json:"collections"`
 	Count       int              `json:"count"`
 }
+
+// --- lidarr tool inputs ---
+
+// ArtistArgs is the input for tools scoped to one artist.
+type ArtistArgs struct {
+	InstanceArg
+	ArtistID int `json:"artistId" jsonschema:"artist id from lidarr_list_artists"`
+}
+
+// RefreshArtistArgs is the input for lidarr_refresh_artist.
+type RefreshArtistArgs struct {
+	InstanceArg
+	WaitArg
+	ArtistID int `json:"artistId" jsonschema:"artist id from lidarr_list_artists"`
+}
+
+// AddArtistArgs is the input for lidarr_add_artist.
+type AddArtistArgs struct {
+	InstanceArg
+	ForeignArtistID   string `json:"foreignArtistId" jsonschema:"MusicBrainz artist id from lidarr_search_artists"`
+	ArtistName        string `json:"artistName,omitempty"`
+	QualityProfileID  int    `json:"qualityProfileId" jsonschema:"id from lidarr_list_quality_profiles"`
+	MetadataProfileID int    `json:"metadataProfileId" jsonschema:"id from lidarr_list_metadata_profiles"`
+	RootFolderPath    string `json:"rootFolderPath" jsonschema:"path from lidarr_list_root_folders"`
+	SearchNow         bool   `json:"searchNow,omitempty" jsonschema:"start searching for missing albums immediately"`
+}
+
+// EditArtistsArgs is the input for lidarr_edit_artists.
+type EditArtistsArgs struct {
+	InstanceArg
+	ArtistIDs         []int  `json:"artistIds" jsonschema:"artist ids from lidarr_list_artists"`
+	Monitored         *bool  `json:"monitored,omitempty" jsonschema:"monitor or unmonitor the artists"`
+	QualityProfileID  *int   `json:"qualityProfileId,omitempty" jsonschema:"id from lidarr_list_quality_profiles"`
+	MetadataProfileID *int   `json:"metadataProfileId,omitempty" jsonschema:"id from lidarr_list_metadata_profiles"`
+	RootFolderPath    string `json:"rootFolderPath,omitempty" jsonschema:"path from lidarr_list_root_folders"`
+	MonitorNewItems   string `json:"monitorNewItems,omitempty" jsonschema:"all, none or new"`
+	Tags              []int  `json:"tags,omitempty" jsonschema:"tag ids from lidarr_list_tags"`
+	ApplyTags         string `json:"applyTags,omitempty" jsonschema:"how to apply tags: add, remove or replace; defaults to add"`
+	MoveFiles         bool   `json:"moveFiles,omitempty" jsonschema:"move files on disk when rootFolderPath changes"`
+}
+
+// AlbumMonitorArgs is the input for lidarr_monitor_albums.
+type AlbumMonitorArgs struct {
+	InstanceArg
+	AlbumIDs  []int `json:"albumIds" jsonschema:"album ids from lidarr_list_albums"`
+	Monitored bool  `json:"monitored" jsonschema:"true to monitor the albums, false to unmonitor them"`
+}
+
+// AlbumSearchArgs is the input for lidarr_trigger_search.
+type AlbumSearchArgs struct {
+	InstanceArg
+	WaitArg
+	ArtistID int   `json:"artistId,omitempty" jsonschema:"search every monitored album of this artist"`
+	AlbumIDs []int `json:"albumIds,omitempty" jsonschema:"search specific albums only; overrides artistId"`
+}
+
+// --- lidarr tool outputs ---
+
+// ArtistList wraps artist results.
+type ArtistList struct {
+	Artists []arr.Artist `json:"artists"`
+	Count   int          `json:"count"`
+}
+
+// AlbumList wraps album results. Total is set only by the paged tools, where
+// the page is capped and the count alone would understate the library.
+type AlbumList struct {
+	Albums []arr.Album `json:"albums"`
+	Count  int         `json:"count"`
+	Total  int         `json:"total,omitempty" jsonschema:"records available across all pages"`
+}
+
+// MetadataProfileList wraps Lidarr and Readarr metadata profile results.
+type MetadataProfileList struct {
+	Profiles []arr.MetadataProfile `json:"profiles"`
+}
+
+// --- readarr tool inputs ---
+
+// AuthorArgs is the input for tools scoped to one author.
+type AuthorArgs struct {
+	InstanceArg
+	AuthorID int `json:"authorId" jsonschema:"author id from readarr_list_authors"`
+}
+
+// AddAuthorArgs is the input for readarr_add_author.
+type AddAuthorArgs struct {
+	InstanceArg
+	ForeignAuthorID   string `json:"foreignAuthorId" jsonschema:"metadata author id from readarr_search_authors"`
+	AuthorName        string `json:"authorName,omitempty"`
+	QualityProfileID  int    `json:"qualityProfileId" jsonschema:"id from readarr_list_quality_profiles"`
+	MetadataProfileID int    `json:"metadataProfileId" jsonschema:"id from readarr_list_metadata_profiles"`
+	RootFolderPath    string `json:"rootFolderPath" jsonschema:"path from readarr_list_root_folders"`
+	SearchNow         bool   `json:"searchNow,omitempty" jsonschema:"start searching for missing books immediately"`
+}
+
+// BookMonitorArgs is the input for readarr_monitor_books.
+type BookMonitorArgs struct {
+	InstanceArg
+	BookIDs   []int `json:"bookIds" jsonschema:"book ids from readarr_list_books"`
+	Monitored bool  `json:"monitored" jsonschema:"true to monitor the books, false to unmonitor them"`
+}
+
+// BookSearchArgs is the input for readarr_trigger_search.
+type BookSearchArgs struct {
+	InstanceArg
+	WaitArg
+	AuthorID int   `json:"authorId,omitempty" jsonschema:"search every monitored book of this author"`
+	BookIDs  []int `json:"bookIds,omitempty" jsonschema:"search specific books only; overrides authorId"`
+}
+
+// --- readarr tool outputs ---
+
+// AuthorList wraps author results.
+type AuthorList struct {
+	Authors []arr.Author `json:"authors"`
+	Count   int          `json:"count"`
+}
+
+// BookList wraps book results. Total is set only by the paged tools, like
+// AlbumList.
+type BookList struct {
+	Books []arr.Book `json:"books"`
+	Count int        `json:"count"`
+	Total int        `json:"total,omitempty" jsonschema:"records available across all pages"`
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+	"github.com/GauranshMathur/ARR_MCP/pkg/tracing"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestToolCallTracesAuthorizeAndUpstreamRequests(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		spans []map[string]any
+	)
+	col := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(col.Close)
+	exporter := tracing.Enable(config.TracingConfig{Endpoint: col.URL, ServiceName: "arr-mcp"}, "test")
+	t.Cleanup(func() { exporter.Shutdown(context.Background()) })
+
+	srv, _ := fakeArr(t, `{"id":1,"label":"4k"}`)
+	cfg := cfgWith(map[string][]config.Instance{
+		"sonarr": {{Name: "main", URL: srv.URL, APIKey: "supersecretkey", Default: true}},
+	}, permsFull)
+	res, err := connect(t, cfg).CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_create_tag",
+		Arguments: map[string]any{"label": "4k"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if _, err := connect(t, cfg).CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "sonarr_run_command",
+		Arguments: map[string]any{"name": "RssSync"},
+	}); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exporter.Flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	byName := map[string]map[string]any{}
+	for _, s := range spans {
+		byName[s["name"].(string)] = s
+	}
+	call, auth, upstream := byName["tools/call sonarr_create_tag"], byName["authorize sonarr_create_tag"], byName["POST /api/v3/tag"]
+	if call == nil || auth == nil || upstream == nil {
+		t.Fatalf("exported spans = %v, want the tool call, authorize and upstream request", spans)
+	}
+	if auth["parentSpanId"] != call["spanId"] || upstream["parentSpanId"] != call["spanId"] {
+		t.Errorf("authorize and upstream spans are not children of the tool call span")
+	}
+	raw, _ := json.Marshal(upstream)
+	for _, want := range []string{`"arr.instance"`, `"stringValue":"main"`, `"url.path"`, `"arr.service"`} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("upstream span %s lacks %s", raw, want)
+		}
+	}
+	// The command is chosen by the model, so it is an attribute, not part of
+	// the span name.
+	command := byName["authorize sonarr_run_command"]
+	if command == nil || byName["authorize sonarr_run_command:RssSync"] != nil {
+		t.Fatalf("exported spans = %v, want the run_command authorize span under the bare tool name", spans)
+	}
+	if raw, _ := json.Marshal(command); !strings.Contains(string(raw), `"mcp.tool.argument"`) || !strings.Contains(string(raw), `"stringValue":"RssSync"`) {
+		t.Errorf("authorize span %s lacks the command as mcp.tool.argument", raw)
+	}
+	all, _ := json.Marshal(spans)
+	if strings.Contains(string(all), "supersecretkey") {
+		t.Error("exported spans contain the API key")
+	}
+}
@@ -0,0 +1,207 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+)
+
+// Export batching. Spans are sent when a batch fills or the interval passes,
+// whichever comes first; once the queue is full new spans are dropped rather
+// than slowing tool calls down.
+var (
+	batchSize     = 256
+	queueSize     = 4096
+	flushInterval = 5 * time.Second
+)
+
+// Exporter batches finished spans and posts them to an OTLP/HTTP collector.
+type Exporter struct {
+	url     string
+	headers map[string]string
+	service string
+	version string
+	http    *http.Client
+
+	queue chan *Span
+	flush chan chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	// OnError, when set, is told about failed exports. They are otherwise
+	// dropped: tracing must never break the calls it observes.
+	OnError func(error)
+}
+
+// Enable begins exporting spans as configured and makes the exporter active.
+// It returns nil, and leaves tracing off, when no endpoint is configured.
+func Enable(cfg config.TracingConfig, version string) *Exporter {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	e := &Exporter{
+		url:     strings.TrimSuffix(cfg.Endpoint, "/") + "/v1/traces",
+		headers: cfg.Headers,
+		service: cfg.ServiceName,
+		version: version,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan *Span, queueSize),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go e.run()
+	active.Store(e)
+	return e
+}
+
+func (e *Exporter) enqueue(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+	}
+}
+
+func (e *Exporter) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	var batch []*Span
+	send := func() {
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-e.flush:
+			for drained := false; !drained; {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(ack)
+		case <-e.done:
+			return
+		}
+	}
+}
+
+// Flush exports every span ended so far, waiting until ctx is done at most.
+func (e *Exporter) Flush(ctx context.Context) {
+	if e == nil {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case e.flush <- ack:
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-ack:
+	case <-ctx.Done():
+	}
+}
+
+// Shutdown flushes outstanding spans and turns tracing off.
+func (e *Exporter) Shutdown(ctx context.Context) {
+	if e == nil {
+		return
+	}
+	e.Flush(ctx)
+	active.CompareAndSwap(e, nil)
+	e.once.Do(func() { close(e.done) })
+}
+
+func (e *Exporter) export(spans []*Span) {
+	body, err := json.Marshal(e.payload(spans))
+	if err == nil {
+		err = e.post(body)
+	}
+	if err != nil && e.OnError != nil {
+		e.OnError(fmt.Errorf("exporting %d span(s) to %s: %w", len(spans), e.url, err))
+	}
+}
+
+func (e *Exporter) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// payload builds an ExportTraceServiceRequest in OTLP's JSON encoding.
+func (e *Exporter) payload(spans []*Span) map[string]any {
+	out := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        attributes(s.attrs),
+		}
+		if s.parent != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.parent[:])
+		}
+		if s.err != nil {
+			span["status"] = map[string]any{"code": 2, "message": s.err.Error()}
+		}
+		out = append(out, span)
+	}
+	return map[string]any{"resourceSpans": []any{map[string]any{
+		"resource": map[string]any{"attributes": attributes([]Attr{
+			String("service.name", e.service),
+			String("service.version", e.version),
+		})},
+		"scopeSpans": []any{map[string]any{
+			"scope": map[string]any{"name": "github.com/GauranshMathur/ARR_MCP", "version": e.version},
+			"spans": out,
+		}},
+	}}}
+}
+
+func attributes(attrs []Attr) []map[string]any {
+	out := make([]map[string]any, 0, len(attrs))
+	for _, a := range attrs {
+		out = append(out, map[string]any{"key": a.Key, "value": otlpValue(a.Value)})
+	}
+	return out
+}
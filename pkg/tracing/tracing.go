@@ -0,0 +1,120 @@
+// Package tracing records spans for tool calls and upstream requests and
+// exports them to an OpenTelemetry collector over OTLP/HTTP.
+//
+// Until Enable is called every function here is a no-op, so instrumented code
+// pays nothing when tracing is not configured.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Span kinds, as numbered by OTLP.
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// Attr is one span attribute. Values are strings or ints.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String builds a string attribute.
+func String(k, v string) Attr { return Attr{k, v} }
+
+// Int builds an integer attribute.
+func Int(k string, v int) Attr { return Attr{k, int64(v)} }
+
+// Span is one timed operation. A nil *Span is valid and records nothing.
+type Span struct {
+	traceID [16]byte
+	spanID  [8]byte
+	parent  [8]byte
+	name    string
+	kind    int
+	start   time.Time
+	end     time.Time
+	attrs   []Attr
+	err     error
+}
+
+// SetAttrs adds attributes after the span has started.
+func (s *Span) SetAttrs(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End finishes the span, marking it failed when err is non-nil, and queues
+// it for export.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+	if e := active.Load(); e != nil {
+		e.enqueue(s)
+	}
+}
+
+type spanKey struct{}
+type attrsKey struct{}
+
+// Start opens a span as a child of any span already in ctx. Attributes
+// attached to ctx with WithAttrs are added to it as well.
+func Start(ctx context.Context, name string, kind int, attrs ...Attr) (context.Context, *Span) {
+	if active.Load() == nil {
+		return ctx, nil
+	}
+	s := &Span{name: name, kind: kind, start: time.Now()}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		s.traceID, s.parent = parent.traceID, parent.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	_, _ = rand.Read(s.spanID[:])
+	if inherited, ok := ctx.Value(attrsKey{}).([]Attr); ok {
+		s.attrs = append(s.attrs, inherited...)
+	}
+	s.attrs = append(s.attrs, attrs...)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// WithAttrs attaches attributes to every span later started from ctx. It lets
+// a caller that knows, say, the instance name tag spans opened deeper down by
+// code that does not.
+func WithAttrs(ctx context.Context, attrs ...Attr) context.Context {
+	if active.Load() == nil {
+		return ctx
+	}
+	inherited, _ := ctx.Value(attrsKey{}).([]Attr)
+	merged := append(append([]Attr(nil), inherited...), attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool { return active.Load() != nil }
+
+// active is the running exporter, or nil while tracing is off.
+var active atomic.Pointer[Exporter]
+
+// otlpValue renders an attribute value in OTLP's JSON AnyValue form, which
+// carries 64-bit integers as strings.
+func otlpValue(v any) map[string]any {
+	switch v := v.(type) {
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case string:
+		return map[string]any{"stringValue": v}
+	}
+	return map[string]any{"stringValue": ""}
+}
@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GauranshMathur/ARR_MCP/pkg/config"
+)
+
+// otlpSpan is the part of an exported span these tests inspect.
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Attributes   []struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s otlpSpan) attr(key string) any {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			for _, v := range a.Value {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// collector is an OTLP/HTTP endpoint that keeps every span it receives.
+type collector struct {
+	url     string
+	mu      sync.Mutex
+	spans   []otlpSpan
+	headers http.Header
+}
+
+// received returns the spans received so far.
+func (c *collector) received() []otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]otlpSpan(nil), c.spans...)
+}
+
+func newCollector(t *testing.T) *collector {
+	t.Helper()
+	c := &collector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.headers = r.Header.Clone()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c.url = srv.URL
+	return c
+}
+
+func enable(t *testing.T, endpoint string, headers map[string]string) *Exporter {
+	t.Helper()
+	e := Enable(config.TracingConfig{Endpoint: endpoint, Headers: headers, ServiceName: "arr-mcp"}, "test")
+	t.Cleanup(func() { e.Shutdown(context.Background()) })
+	return e
+}
+
+func TestDisabledTracingRecordsNothing(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop", KindInternal)
+	if span != nil || ctx != context.Background() {
+		t.Fatalf("Start without Enable = %v, want a nil span and the same context", span)
+	}
+	span.SetAttrs(String("k", "v"))
+	span.End(errors.New("ignored"))
+}
+
+func TestChildSpansShareTheTraceAndInheritAttributes(t *testing.T) {
+	col := newCollector(t)
+	e := enable(t, col.url, map[string]string{"Authorization": "Bearer collector"})
+
+	ctx := WithAttrs(context.Background(), String("arr.instance", "main"))
+	ctx, parent := Start(ctx, "tools/call sonarr_list_series", KindServer)
+	_, child := Start(ctx, "GET /api/v3/series", KindClient, Int("http.response.status_code", 200))
+	child.End(nil)
+	parent.End(errors.New("boom"))
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.Flush(flushCtx)
+
+	spans := col.received()
+	if len(spans) != 2 {
+		t.Fatalf("collector received %d spans, want 2", len(spans))
+	}
+	got, want := spans[0], spans[1]
+	if got.TraceID != want.TraceID || got.ParentSpanID != want.SpanID || want.ParentSpanID != "" {
+		t.Errorf("child %+v is not parented to %+v", got, want)
+	}
+	if got.attr("arr.instance") != "main" || got.attr("http.response.status_code") != "200" {
+		t.Errorf("child attributes = %+v", got.Attributes)
+	}
+	if got.Kind != KindClient || got.Status != nil {
+		t.Errorf("child kind/status = %d/%+v, want a successful client span", got.Kind, got.Status)
+	}
+	if want.Status == nil || want.Status.Code != 2 || want.Status.Message != "boom" {
+		t.Errorf("parent status = %+v, want an error carrying the message", want.Status)
+	}
+	if h := col.headers.Get("Authorization"); h != "Bearer collector" {
+		t.Errorf("collector saw Authorization %q, want the configured header", h)
+	}
+}